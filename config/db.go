@@ -37,7 +37,45 @@ func ConnectDB() {
 	DB = db
 
 	// Migrate the schemas
-	DB.AutoMigrate(&models.User{}, &models.Task{}, &models.Comment{}, &models.History{})
+	DB.AutoMigrate(
+		&models.User{},
+		&models.Project{},
+		&models.ProjectMember{},
+		&models.CustomField{},
+		&models.Sprint{},
+		&models.Task{},
+		&models.TaskLink{},
+		&models.TaskAssignee{},
+		&models.TaskWatcher{},
+		&models.SprintTask{},
+		&models.Label{},
+		&models.SavedFilter{},
+		&models.Comment{},
+		&models.CommentMention{},
+		&models.CommentReaction{},
+		&models.Attachment{},
+		&models.History{},
+		&models.Notification{},
+		&models.OutboxEvent{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.RefreshToken{},
+		&models.RevokedToken{},
+		&models.Workflow{},
+		&models.WorkflowState{},
+		&models.WorkflowTransition{},
+	)
+	migrateSearch()
 	fmt.Println("Database Migrated!")
 
+	// Seed the default workflow used by tasks without a custom one
+	var workflows int64
+	DB.Model(&models.Workflow{}).Where("project_id IS NULL").Count(&workflows)
+	if workflows == 0 {
+		workflow := models.DefaultWorkflow()
+		if err := DB.Create(&workflow).Error; err != nil {
+			log.Fatal("Failed to seed default workflow:", err)
+		}
+	}
+
 }
@@ -0,0 +1,25 @@
+package config
+
+import "log"
+
+// Full-text search columns are generated by Postgres, so they are kept out of
+// the models and created here instead of by AutoMigrate
+var searchMigrations = []string{
+	`ALTER TABLE tasks ADD COLUMN IF NOT EXISTS search_vector tsvector
+		GENERATED ALWAYS AS (
+			setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+			setweight(to_tsvector('english', coalesce(description, '')), 'B')
+		) STORED`,
+	`CREATE INDEX IF NOT EXISTS idx_tasks_search_vector ON tasks USING GIN (search_vector)`,
+	`ALTER TABLE comments ADD COLUMN IF NOT EXISTS search_vector tsvector
+		GENERATED ALWAYS AS (to_tsvector('english', coalesce(content, ''))) STORED`,
+	`CREATE INDEX IF NOT EXISTS idx_comments_search_vector ON comments USING GIN (search_vector)`,
+}
+
+func migrateSearch() {
+	for _, migration := range searchMigrations {
+		if err := DB.Exec(migration).Error; err != nil {
+			log.Fatal("Failed to migrate search columns:", err)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"task-management-api/config"
+	"task-management-api/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Handler consumes an event. Its changes are made with tx, which commits them together with
+// the event being marked as handled, so a handler that fails is retried without leaving anything behind.
+type Handler func(tx *gorm.DB, event models.OutboxEvent) error
+
+type subscriber struct {
+	name    string
+	types   []string
+	handler Handler
+}
+
+var subscribers []subscriber
+
+// changed is closed and replaced whenever events are dispatched, to wake up the streams waiting on it
+var (
+	changedMu sync.Mutex
+	changed   = make(chan struct{})
+)
+
+const (
+	batchSize   = 100
+	baseBackoff = 5 * time.Second
+	maxBackoff  = time.Hour
+)
+
+// Subscribe registers a handler for the given event types. The name records which
+// subscribers are done with an event, so it must not change between releases.
+func Subscribe(name string, handler Handler, types ...string) {
+	subscribers = append(subscribers, subscriber{name: name, types: types, handler: handler})
+}
+
+// Publish adds an event about the task to the outbox, within the transaction of the change it describes
+func Publish(tx *gorm.DB, eventType string, task models.Task, actorID string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&models.OutboxEvent{
+		Type:          eventType,
+		AggregateID:   task.ID,
+		ProjectID:     task.ProjectID,
+		ActorID:       actorID,
+		Payload:       string(body),
+		Handled:       []string{},
+		NextAttemptAt: time.Now(),
+	}).Error
+}
+
+// Dispatch processes the pending events of the aggregate right away, after the change that
+// published them is committed. What fails is left to the outbox job.
+func Dispatch(aggregateID string) {
+	if err := process(config.DB.Where("aggregate_id = ? AND next_attempt_at <= ?", aggregateID, time.Now())); err != nil {
+		log.Println("Failed to dispatch events:", err)
+	}
+	notifyChanged()
+}
+
+// Changed returns a channel closed the next time events published by this instance are dispatched
+func Changed() <-chan struct{} {
+	changedMu.Lock()
+	defer changedMu.Unlock()
+	return changed
+}
+
+func notifyChanged() {
+	changedMu.Lock()
+	defer changedMu.Unlock()
+	close(changed)
+	changed = make(chan struct{})
+}
+
+// DispatchDue processes the pending events that are due
+func DispatchDue() error {
+	return process(config.DB.Where("next_attempt_at <= ?", time.Now()))
+}
+
+// Retry gives a failed event a fresh set of attempts and processes it right away.
+// It returns gorm.ErrRecordNotFound when the event is not a failed one.
+func Retry(id int64) error {
+	var event models.OutboxEvent
+	if err := config.DB.First(&event, "id = ? AND failed_at IS NOT NULL", id).Error; err != nil {
+		return err
+	}
+	if err := config.DB.Model(&event).Select("failed_at", "attempts", "next_attempt_at").
+		Updates(models.OutboxEvent{FailedAt: nil, Attempts: 0, NextAttemptAt: time.Now()}).Error; err != nil {
+		return err
+	}
+	Dispatch(event.AggregateID)
+	return nil
+}
+
+// maxAttempts is how many times an event is tried before it is marked failed, OUTBOX_MAX_ATTEMPTS (default 10)
+func maxAttempts() int {
+	if attempts, err := strconv.Atoi(os.Getenv("OUTBOX_MAX_ATTEMPTS")); err == nil && attempts > 0 {
+		return attempts
+	}
+	return 10
+}
+
+// process handles the pending events selected by query, oldest first. An event waits for the
+// earlier events of its aggregate, so subscribers see the changes of a task in order, but not
+// for failed ones.
+func process(query *gorm.DB) error {
+	for {
+		var ids []int64
+		if err := query.Session(&gorm.Session{}).Model(&models.OutboxEvent{}).
+			Where("processed_at IS NULL AND failed_at IS NULL").
+			Where("NOT EXISTS (SELECT 1 FROM outbox_events earlier WHERE earlier.aggregate_id = outbox_events.aggregate_id AND earlier.id < outbox_events.id AND earlier.processed_at IS NULL AND earlier.failed_at IS NULL)").
+			Order("id").Limit(batchSize).Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+
+		handled := 0
+		for _, id := range ids {
+			done, err := handle(id)
+			if err != nil {
+				return err
+			}
+			if done {
+				handled++
+			}
+		}
+
+		// Handlers can publish events of their own, keep going while events get processed
+		if handled == 0 {
+			return nil
+		}
+	}
+}
+
+// handle runs the subscribers that have not handled the event yet, each in a savepoint, and records
+// the outcome. It returns whether the event is now processed. Events locked by another worker are skipped.
+func handle(id int64) (bool, error) {
+	processed := false
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var event models.OutboxEvent
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("id = ? AND processed_at IS NULL AND failed_at IS NULL", id).Take(&event).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var failures []error
+		for _, s := range subscribers {
+			if !slices.Contains(s.types, event.Type) || slices.Contains(event.Handled, s.name) {
+				continue
+			}
+			if err := tx.Transaction(func(sp *gorm.DB) error { return s.handler(sp, event) }); err != nil {
+				failures = append(failures, errors.New(s.name+": "+err.Error()))
+				continue
+			}
+			event.Handled = append(event.Handled, s.name)
+		}
+
+		if len(failures) == 0 {
+			now := time.Now()
+			event.ProcessedAt = &now
+			event.LastError = ""
+			processed = true
+		} else {
+			event.Attempts++
+			event.NextAttemptAt = time.Now().Add(backoff(event.Attempts))
+			event.LastError = errors.Join(failures...).Error()
+			if event.Attempts >= maxAttempts() {
+				now := time.Now()
+				event.FailedAt = &now
+				log.Printf("Event %d (%s) of %s gave up after %d attempts, retry it from /api/v1/events/failed: %s", event.ID, event.Type, event.AggregateID, event.Attempts, event.LastError)
+			} else {
+				log.Printf("Event %d (%s) failed: %s", event.ID, event.Type, event.LastError)
+			}
+		}
+		return tx.Model(&event).Select("handled", "attempts", "next_attempt_at", "last_error", "processed_at", "failed_at").Updates(&event).Error
+	})
+	return processed, err
+}
+
+// backoff doubles from 5s up to an hour
+func backoff(attempts int) time.Duration {
+	wait := baseBackoff
+	for i := 1; i < attempts && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, maxBackoff)
+}
@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"task-management-api/config"
+	"task-management-api/models"
+	"task-management-api/storage"
+	"task-management-api/utils"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// GetTaskAttachments lists the files attached to a task and to its comments, oldest first
+func GetTaskAttachments(c *fiber.Ctx) error {
+	task, ok := getVisibleTask(c)
+	if !ok {
+		return nil
+	}
+
+	attachments, err := getTaskAttachments(task.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve attachments"})
+	}
+
+	return c.JSON(attachments)
+}
+
+// UploadTaskAttachment attaches the multipart "file" to a task, for the members of its project
+func UploadTaskAttachment(c *fiber.Ctx) error {
+	task, ok := getVisibleTask(c)
+	if !ok {
+		return nil
+	}
+
+	return uploadAttachment(c, task.ID, nil)
+}
+
+// UploadCommentAttachment attaches the multipart "file" to a comment, for its author or a moderator
+func UploadCommentAttachment(c *fiber.Ctx) error {
+	var comment models.Comment
+	if err := config.DB.First(&comment, "id = ?", c.Params("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Comment not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve comment"})
+	}
+
+	var task models.Task
+	if err := config.DB.First(&task, "id = ?", comment.TaskID).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve task"})
+	}
+	if !canViewTask(c, task) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Comment not found"})
+	}
+
+	user := GetUserByID(c)
+	if !utils.HasPermission(GetRole(c), utils.CommentModerate, comment.CreatedBy, user.ID) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "You are not authorized to update this comment"})
+	}
+
+	return uploadAttachment(c, comment.TaskID, &comment.ID)
+}
+
+// DownloadAttachment redirects to a signed URL when the backend has them, or streams the file.
+// Attachments of project tasks are only served to the project members.
+func DownloadAttachment(c *fiber.Ctx) error {
+	var attachment models.Attachment
+	if err := config.DB.First(&attachment, "id = ?", c.Params("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Attachment not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve attachment"})
+	}
+
+	var task models.Task
+	if err := config.DB.First(&task, "id = ?", attachment.TaskID).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve task"})
+	}
+	if !canViewTask(c, task) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Attachment not found"})
+	}
+
+	if signer, ok := storage.Files.(storage.URLSigner); ok {
+		url, err := signer.SignedURL(attachment.StorageKey, 5*time.Minute)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to sign download URL"})
+		}
+		return c.Redirect(url, fiber.StatusFound)
+	}
+
+	file, err := storage.Files.Get(attachment.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Attachment file not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to read attachment"})
+	}
+
+	c.Attachment(attachment.FileName)
+	c.Set(fiber.HeaderContentType, attachment.ContentType)
+	c.Set("X-Content-Type-Options", "nosniff")
+	return c.SendStream(file, int(attachment.Size))
+}
+
+// DeleteAttachment removes an attachment, for its uploader or a role that can delete any
+func DeleteAttachment(c *fiber.Ctx) error {
+	var attachment models.Attachment
+	if err := config.DB.First(&attachment, "id = ?", c.Params("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Attachment not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve attachment"})
+	}
+
+	if visible, err := canViewTaskID(c, attachment.TaskID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve task"})
+	} else if !visible {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Attachment not found"})
+	}
+
+	user := GetUserByID(c)
+	if !utils.HasPermission(GetRole(c), utils.AttachmentDeleteAny, attachment.CreatedBy, user.ID) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not authorized to delete this attachment"})
+	}
+
+	if err := config.DB.Delete(&attachment).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete attachment"})
+	}
+	removeFiles(attachment.StorageKey)
+
+	return c.Status(fiber.StatusOK).SendString("Attachment deleted")
+}
+
+// uploadAttachment checks the size and type of the uploaded file, stores it and records it
+func uploadAttachment(c *fiber.Ctx, taskID string, commentID *string) error {
+	user := GetUserByID(c)
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "A multipart file field named file is required"})
+	}
+	file, contentType, status, err := openUpload(header)
+	if err != nil {
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+	defer file.Close()
+
+	attachment := models.Attachment{
+		ID:          uuid.NewString(),
+		TaskID:      taskID,
+		CommentID:   commentID,
+		FileName:    filepath.Base(header.Filename),
+		ContentType: contentType,
+		Size:        header.Size,
+		CreatedBy:   user.ID,
+	}
+	attachment.StorageKey = "tasks/" + taskID + "/" + attachment.ID
+
+	// Store the file first, and remove it again if it cannot be recorded
+	if err := storage.Files.Put(attachment.StorageKey, file, contentType); err != nil {
+		log.Println("Failed to store attachment:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to store file"})
+	}
+	if err := config.DB.Create(&attachment).Error; err != nil {
+		removeFiles(attachment.StorageKey)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create attachment"})
+	}
+	attachment.User = user
+
+	return c.Status(fiber.StatusCreated).JSON(models.FormatAttachmentResponse(attachment))
+}
+
+// openUpload checks the size and the type of an uploaded file and opens it
+func openUpload(header *multipart.FileHeader) (multipart.File, string, int, error) {
+	if header.Size == 0 {
+		return nil, "", fiber.StatusBadRequest, errors.New("File is empty")
+	}
+	if header.Size > storage.MaxSize {
+		return nil, "", fiber.StatusRequestEntityTooLarge, errors.New("File is larger than " + strconv.FormatInt(storage.MaxSize, 10) + " bytes")
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return nil, "", fiber.StatusBadRequest, errors.New("Failed to read file")
+	}
+
+	contentType, err := attachmentContentType(file, header.Header.Get(fiber.HeaderContentType))
+	if err != nil {
+		file.Close()
+		return nil, "", fiber.StatusBadRequest, errors.New("Failed to read file")
+	}
+	if !slices.Contains(storage.AllowedTypes, contentType) {
+		file.Close()
+		return nil, "", fiber.StatusUnsupportedMediaType, errors.New("File type " + contentType + " is not allowed")
+	}
+	return file, contentType, 0, nil
+}
+
+// attachmentContentType sniffs the type from the file's first bytes. Text files keep a more
+// specific declared text type (e.g. text/csv or application/json), other declarations are ignored.
+func attachmentContentType(file io.ReadSeeker, declared string) (string, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(head[:n]))
+	declared, _, _ = mime.ParseMediaType(declared)
+	if sniffed == "text/plain" && (strings.HasPrefix(declared, "text/") || declared == "application/json") {
+		return declared, nil
+	}
+	return sniffed, nil
+}
+
+func getTaskAttachments(taskID string) ([]models.AttachmentResponse, error) {
+	var attachments []models.Attachment
+	if err := config.DB.Preload("User").Where("task_id = ?", taskID).Order("created_at, id").Find(&attachments).Error; err != nil {
+		return nil, err
+	}
+
+	response := []models.AttachmentResponse{}
+	for _, attachment := range attachments {
+		response = append(response, models.FormatAttachmentResponse(attachment))
+	}
+	return response, nil
+}
+
+// deleteAttachments deletes the attachment records matching the condition and returns their storage keys,
+// so the files can be removed once the change is done
+func deleteAttachments(db *gorm.DB, query interface{}, args ...interface{}) ([]string, error) {
+	var keys []string
+	if err := db.Model(&models.Attachment{}).Where(query, args...).Pluck("storage_key", &keys).Error; err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	return keys, db.Where(query, args...).Delete(&models.Attachment{}).Error
+}
+
+// removeFiles deletes stored files. Failures are logged, the records are already gone.
+func removeFiles(keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+	if err := storage.Files.Delete(keys...); err != nil {
+		log.Println("Failed to remove attachment files:", err)
+	}
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+	"task-management-api/storage"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00")
+
+func TestAttachmentContentType(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		content  []byte
+		declared string
+		want     string
+	}{
+		{"sniffed png", pngHeader, "", "image/png"},
+		{"png declared as octet stream", pngHeader, "application/octet-stream", "image/png"},
+		{"text declared as csv", []byte("id,title\n1,Ship it\n"), "text/csv", "text/csv"},
+		{"text declared as csv with charset", []byte("id,title\n1,Ship it\n"), "text/csv; charset=utf-8", "text/csv"},
+		{"text declared as json", []byte(`{"title":"Ship it"}`), "application/json", "application/json"},
+		{"text without a declared type", []byte("just some notes"), "", "text/plain"},
+		{"text declared as png", []byte("just some notes"), "image/png", "text/plain"},
+		{"png declared as csv", pngHeader, "text/csv", "image/png"},
+		{"html declared as plain text", []byte("<html><script>alert(1)</script></html>"), "text/plain", "text/html"},
+	} {
+		file := bytes.NewReader(tt.content)
+		got, err := attachmentContentType(file, tt.declared)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: attachmentContentType = %q, want %q", tt.name, got, tt.want)
+		}
+		// the file is read again from the start when it is stored
+		if file.Len() != len(tt.content) {
+			t.Errorf("%s: file left at offset %d, want it rewound", tt.name, len(tt.content)-file.Len())
+		}
+	}
+}
+
+// uploadHeader builds the header of an uploaded file the way a multipart form is parsed
+func uploadHeader(t *testing.T, content []byte, contentType string) *multipart.FileHeader {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	head := textproto.MIMEHeader{}
+	head.Set("Content-Disposition", `form-data; name="file"; filename="upload"`)
+	if contentType != "" {
+		head.Set("Content-Type", contentType)
+	}
+	part, err := form.CreatePart(head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(content)
+	form.Close()
+
+	parsed, err := multipart.NewReader(&body, form.Boundary()).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { parsed.RemoveAll() })
+	return parsed.File["file"][0]
+}
+
+func TestOpenUpload(t *testing.T) {
+	previous := storage.MaxSize
+	storage.MaxSize = 64
+	t.Cleanup(func() { storage.MaxSize = previous })
+
+	for _, tt := range []struct {
+		name        string
+		content     []byte
+		declared    string
+		status      int
+		contentType string
+	}{
+		{"png", pngHeader, "image/png", 0, "image/png"},
+		{"csv", []byte("id,title\n1,Ship it\n"), "text/csv", 0, "text/csv"},
+		{"empty", nil, "text/plain", fiber.StatusBadRequest, ""},
+		{"too large", bytes.Repeat([]byte("a"), 65), "text/plain", fiber.StatusRequestEntityTooLarge, ""},
+		{"html", []byte("<html></html>"), "text/plain", fiber.StatusUnsupportedMediaType, ""},
+		{"executable declared as png", []byte("MZ\x90\x00\x03\x00\x00\x00\x04\x00\x00\x00\xff\xff"), "image/png", fiber.StatusUnsupportedMediaType, ""},
+	} {
+		file, contentType, status, err := openUpload(uploadHeader(t, tt.content, tt.declared))
+		if tt.status == 0 {
+			if err != nil {
+				t.Errorf("%s: %v", tt.name, err)
+				continue
+			}
+			file.Close()
+			if contentType != tt.contentType {
+				t.Errorf("%s: content type = %q, want %q", tt.name, contentType, tt.contentType)
+			}
+			continue
+		}
+		if err == nil {
+			file.Close()
+			t.Errorf("%s: accepted as %q, want status %d", tt.name, contentType, tt.status)
+			continue
+		}
+		if status != tt.status {
+			t.Errorf("%s: status = %d (%v), want %d", tt.name, status, err, tt.status)
+		}
+	}
+
+	// the allowed types can be narrowed
+	allowed := storage.AllowedTypes
+	storage.AllowedTypes = []string{"image/png"}
+	t.Cleanup(func() { storage.AllowedTypes = allowed })
+	if _, _, status, err := openUpload(uploadHeader(t, []byte("id,title\n"), "text/csv")); err == nil || status != fiber.StatusUnsupportedMediaType {
+		t.Errorf("csv with only png allowed: status = %d, %v, want %d", status, err, fiber.StatusUnsupportedMediaType)
+	} else if !strings.Contains(err.Error(), "text/csv") {
+		t.Errorf("error %q does not name the rejected type", err)
+	}
+	file, _, _, err := openUpload(uploadHeader(t, pngHeader, ""))
+	if err != nil {
+		t.Fatalf("png with only png allowed: %v", err)
+	}
+	file.Close()
+}
@@ -1,18 +1,29 @@
 package handlers
 
 import (
+	"errors"
 	"task-management-api/config"
 	"task-management-api/models"
 	"task-management-api/utils"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
 )
 
+// errRefreshTokenRotated is returned when another request rotated the refresh token first
+var errRefreshTokenRotated = errors.New("refresh token has been revoked")
+
 type AuthRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
 }
 
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
 func SignUp(c *fiber.Ctx) error {
 	var req AuthRequest
 	if err := c.BodyParser(&req); err != nil {
@@ -39,7 +50,7 @@ func SignUp(c *fiber.Ctx) error {
 	}
 
 	// Login user automatically
-	token, err := utils.GenerateToken(user.ID)
+	token, refreshToken, err := issueTokens(config.DB, user.ID)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"message": err.Error(),
@@ -47,8 +58,9 @@ func SignUp(c *fiber.Ctx) error {
 	}
 
 	return c.Status(201).JSON(fiber.Map{
-		"message": "Successfully signed up",
-		"token":   token,
+		"message":       "Successfully signed up",
+		"token":         token,
+		"refresh_token": refreshToken,
 	})
 }
 
@@ -80,7 +92,7 @@ func Login(c *fiber.Ctx) error {
 		})
 	}
 
-	token, err := utils.GenerateToken(user.ID)
+	token, refreshToken, err := issueTokens(config.DB, user.ID)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"message": err.Error(),
@@ -88,6 +100,158 @@ func Login(c *fiber.Ctx) error {
 	}
 
 	return c.JSON(fiber.Map{
-		"token": token,
+		"token":         token,
+		"refresh_token": refreshToken,
+	})
+}
+
+// Refresh route, rotates the refresh token and issues a new access token
+func Refresh(c *fiber.Ctx) error {
+	var req RefreshRequest
+	if err := c.BodyParser(&req); err != nil || req.RefreshToken == "" {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "refresh_token is required",
+		})
+	}
+
+	var stored models.RefreshToken
+	res := config.DB.Where("token_hash = ?", utils.HashToken(req.RefreshToken)).First(&stored)
+	if res.Error != nil {
+		return c.Status(401).JSON(fiber.Map{
+			"message": "invalid refresh token",
+		})
+	}
+
+	// A revoked token being replayed means it leaked, so revoke the whole family
+	if stored.RevokedAt != nil {
+		if err := revokeRefreshTokens(stored.UserID); err != nil {
+			return c.Status(500).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+		return c.Status(401).JSON(fiber.Map{
+			"message": "refresh token has been revoked",
+		})
+	}
+	if time.Now().After(stored.ExpiresAt) {
+		return c.Status(401).JSON(fiber.Map{
+			"message": "refresh token has expired",
+		})
+	}
+
+	// Revoke only if nobody else rotated it in the meantime, together with issuing the new
+	// tokens so a failure does not leave the session without a refresh token
+	var token, refreshToken string
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", stored.ID).
+			Update("revoked_at", time.Now())
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errRefreshTokenRotated
+		}
+
+		var err error
+		token, refreshToken, err = issueTokens(tx, stored.UserID)
+		return err
 	})
+	if errors.Is(err, errRefreshTokenRotated) {
+		return c.Status(401).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"token":         token,
+		"refresh_token": refreshToken,
+	})
+}
+
+// Logout route, revokes the current access token and the given refresh token.
+// Without a refresh token every session of the user is logged out.
+func Logout(c *fiber.Ctx) error {
+	claims := c.Locals("user").(jwt.MapClaims)
+	userID, _ := claims["user_id"].(string)
+
+	// the body, with the refresh token to revoke, is optional
+	var req RefreshRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"message": "Invalid request",
+			})
+		}
+	}
+
+	if jti, ok := claims["jti"].(string); ok {
+		expiresAt := time.Now().Add(utils.AccessTokenTTL)
+		if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+			expiresAt = exp.Time
+		}
+		revoked := models.RevokedToken{JTI: jti, ExpiresAt: expiresAt}
+		if err := config.DB.Create(&revoked).Error; err != nil {
+			return c.Status(500).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+	}
+
+	if req.RefreshToken != "" {
+		res := config.DB.Model(&models.RefreshToken{}).
+			Where("user_id = ? AND token_hash = ? AND revoked_at IS NULL", userID, utils.HashToken(req.RefreshToken)).
+			Update("revoked_at", time.Now())
+		if res.Error != nil {
+			return c.Status(500).JSON(fiber.Map{
+				"message": res.Error.Error(),
+			})
+		}
+	} else if err := revokeRefreshTokens(userID); err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	// Revoked access tokens are only needed until they expire
+	config.DB.Where("expires_at < ?", time.Now()).Delete(&models.RevokedToken{})
+
+	return c.JSON(fiber.Map{
+		"message": "Successfully logged out",
+	})
+}
+
+// issueTokens creates an access token and a refresh token for the user, stored with db
+func issueTokens(db *gorm.DB, userID string) (string, string, error) {
+	token, err := utils.GenerateToken(userID)
+	if err != nil {
+		return "", "", err
+	}
+
+	refreshToken, err := utils.RandomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	stored := models.RefreshToken{
+		UserID:    userID,
+		TokenHash: utils.HashToken(refreshToken),
+		ExpiresAt: time.Now().Add(utils.RefreshTokenTTL),
+	}
+	if err := db.Create(&stored).Error; err != nil {
+		return "", "", err
+	}
+
+	return token, refreshToken, nil
+}
+
+func revokeRefreshTokens(userID string) error {
+	return config.DB.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
 }
@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"errors"
+	"slices"
+	"task-management-api/config"
+	"task-management-api/events"
+	"task-management-api/models"
+	"task-management-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type MoveTaskRequest struct {
+	Status   string `json:"status"`    // the column to move to, the current one when empty
+	AfterID  string `json:"after_id"`  // the task to place it after
+	BeforeID string `json:"before_id"` // the task to place it before
+}
+
+// ranks compare byte by byte whatever the database collation
+const rankOrder = `tasks.rank COLLATE "C", tasks.created_at, tasks.id`
+
+var errBoardChanged = errors.New("The board has changed, reload it and move the task again")
+
+// GetBoard returns the board of the tasks without a project, with the same filters as the task list
+func GetBoard(c *fiber.Ctx) error {
+	return board(c, nil)
+}
+
+// GetProjectBoard returns the board of a project, its columns following the project workflow
+func GetProjectBoard(c *fiber.Ctx) error {
+	project, ok := getProjectForMember(c)
+	if !ok {
+		return nil
+	}
+
+	return board(c, &project.ID)
+}
+
+func board(c *fiber.Ctx, projectID *string) error {
+	filter, status, err := resolveTaskFilter(c)
+	if err != nil {
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+	if filter.Sort != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "sort is not supported on the board"})
+	}
+
+	query, err := applyTaskFilter(c, boardScope(config.DB.Model(&models.Task{}), projectID), filter, projectID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	workflow, err := getWorkflow(projectID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve workflow"})
+	}
+
+	var tasks []models.Task
+	if err := query.Preload("Labels").Preload("CoAssignees").Order(rankOrder).Find(&tasks).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve tasks"})
+	}
+
+	response := models.BoardResponse{ProjectID: projectID, Columns: []models.BoardColumn{}}
+	columns := map[string]int{}
+	for _, state := range workflow.States {
+		columns[state.Name] = len(response.Columns)
+		response.Columns = append(response.Columns, models.BoardColumn{Status: state.Name, Label: state.Label, Category: state.Category, Tasks: []models.TaskResponse{}})
+	}
+	for _, task := range tasks {
+		// tasks left in a state removed from the workflow get a column of their own
+		i, ok := columns[string(task.Status)]
+		if !ok {
+			i = len(response.Columns)
+			columns[string(task.Status)] = i
+			response.Columns = append(response.Columns, models.BoardColumn{Status: string(task.Status), Label: string(task.Status), Tasks: []models.TaskResponse{}})
+		}
+		response.Columns[i].Tasks = append(response.Columns[i].Tasks, renderTask(c, models.FormatTaskResponse(task)))
+	}
+
+	return c.JSON(response)
+}
+
+// MoveTask changes the status of a task and its position in the column together. Moves on a board
+// are serialized, a move placing the task next to tasks that moved meanwhile gets a 409.
+func MoveTask(c *fiber.Ctx) error {
+	user := GetUserByID(c)
+
+	var task models.Task
+	if err := config.DB.Where(taskIDCondition(c.Params("id"))).First(&task).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Task not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve task"})
+	}
+
+	if !canViewTask(c, task) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Task not found"})
+	}
+
+	if !canUpdateTask(c, task, user.ID) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not authorized to update this task"})
+	}
+
+	var req MoveTaskRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	if req.AfterID == task.ID || req.BeforeID == task.ID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "A task cannot be placed next to itself"})
+	}
+	for _, id := range []string{req.AfterID, req.BeforeID} {
+		if id != "" && !utils.IsUUID(id) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "after_id and before_id must be task IDs"})
+		}
+	}
+
+	status := task.Status
+	if req.Status != "" {
+		status = utils.Status(req.Status)
+	}
+	if code, err := checkStatusChange(task, status, GetRole(c)); err != nil {
+		return c.Status(code).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// The rank is left out of the published changes, only a new status makes it to the history
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		rank, err := placeTask(tx, task, status, req.AfterID, req.BeforeID)
+		if err != nil {
+			return err
+		}
+
+		changes, err := taskChanges(tx, task.ID, models.Task{Status: status})
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&task).Updates(models.Task{Status: status, Rank: rank, UpdatedBy: user.ID}).Error; err != nil {
+			return err
+		}
+		return publishTaskUpdated(tx, task.ID, user.ID, changes)
+	})
+	if errors.Is(err, errBoardChanged) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to move task"})
+	}
+	events.Dispatch(task.ID)
+
+	if err := config.DB.Preload("Labels").Preload("CoAssignees").First(&task, "id = ?", task.ID).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve task"})
+	}
+	refreshOverdue(&task)
+
+	return c.JSON(renderTask(c, models.FormatTaskResponse(task)))
+}
+
+// boardScope selects the tasks on the board of the project, tasks without a project share a board
+func boardScope(db *gorm.DB, projectID *string) *gorm.DB {
+	if projectID == nil {
+		return db.Where("tasks.project_id IS NULL")
+	}
+	return db.Where("tasks.project_id = ?", *projectID)
+}
+
+// placeTask returns the rank that puts the task in the status column after afterID and before beforeID,
+// at the end of the column when both are empty. The board stays locked until tx ends, and the column
+// is given fresh ranks when they are missing, tied or too long, which does not change its order.
+func placeTask(tx *gorm.DB, task models.Task, status utils.Status, afterID string, beforeID string) (string, error) {
+	board := "board:"
+	if task.ProjectID != nil {
+		board += *task.ProjectID
+	}
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", board).Error; err != nil {
+		return "", err
+	}
+
+	var column []models.Task
+	query := boardScope(tx.Model(&models.Task{}), task.ProjectID).Select("id", "rank").Where("tasks.status = ?", status)
+	if task.ID != "" {
+		query = query.Where("tasks.id <> ?", task.ID)
+	}
+	if err := query.Order(rankOrder).Find(&column).Error; err != nil {
+		return "", err
+	}
+
+	position := len(column)
+	after := slices.IndexFunc(column, func(t models.Task) bool { return t.ID == afterID })
+	before := slices.IndexFunc(column, func(t models.Task) bool { return t.ID == beforeID })
+	if (afterID != "" && after < 0) || (beforeID != "" && before < 0) || (afterID != "" && beforeID != "" && before != after+1) {
+		return "", errBoardChanged
+	}
+	if afterID != "" {
+		position = after + 1
+	} else if beforeID != "" {
+		position = before
+	}
+
+	if !rankedColumn(column) {
+		if err := spreadColumn(tx, column); err != nil {
+			return "", err
+		}
+	}
+
+	var lo, hi string
+	if position > 0 {
+		lo = column[position-1].Rank
+	}
+	if position < len(column) {
+		hi = column[position].Rank
+	}
+	rank, err := utils.RankBetween(lo, hi)
+	if err != nil {
+		return "", err
+	}
+	if len(rank) <= utils.MaxRankLength {
+		return rank, nil
+	}
+
+	// out of room at this position, respread the column with the task in place
+	column = slices.Insert(column, position, task)
+	if err := spreadColumn(tx, column); err != nil {
+		return "", err
+	}
+	return column[position].Rank, nil
+}
+
+// rankedColumn reports whether the tasks, in board order, all have distinct ranks
+func rankedColumn(column []models.Task) bool {
+	for i, task := range column {
+		if task.Rank == "" || (i > 0 && column[i-1].Rank >= task.Rank) {
+			return false
+		}
+	}
+	return true
+}
+
+// spreadColumn ranks the tasks evenly in their current order, without touching updated_at
+func spreadColumn(tx *gorm.DB, column []models.Task) error {
+	for i, rank := range utils.SpreadRanks(len(column)) {
+		column[i].Rank = rank
+		if column[i].ID == "" {
+			continue
+		}
+		if err := tx.Model(&models.Task{}).Where("id = ?", column[i].ID).UpdateColumn("rank", rank).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
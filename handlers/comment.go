@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"task-management-api/config"
+	"task-management-api/events"
 	"task-management-api/models"
 	"task-management-api/utils"
 
@@ -9,18 +10,30 @@ import (
 	"gorm.io/gorm"
 )
 
+// GetTaskComments lists the comments of a task, oldest first in page mode
+func GetTaskComments(c *fiber.Ctx) error {
+	task, ok := getVisibleTask(c)
+	if !ok {
+		return nil
+	}
+
+	query := config.DB.Model(&models.Comment{}).Preload("User").Preload("Mentions.User").Preload("Reactions.User").Where("task_id = ?", task.ID)
+	if !isCursorMode(c) {
+		query = query.Order("created_at, id")
+	}
+
+	return paginate(c, query, "comments.updated_at", "comments.id", commentCursor, commentFormatter(c))
+}
+
 func CreateComment(c *fiber.Ctx) error {
 	user := GetUserByID(c)
-	taskId := c.Params("id")
 
 	var comment models.Comment
 
-	// validate task id
-	if err := config.DB.Table("tasks").Where("id = ?", taskId).Select("id").Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Task not found"})
-		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve task"})
+	// only the users who can see the task comment on it, and so come to watch it
+	task, ok := getVisibleTask(c)
+	if !ok {
+		return nil
 	}
 
 	// validate payload
@@ -29,14 +42,45 @@ func CreateComment(c *fiber.Ctx) error {
 	}
 
 	comment.CreatedBy = user.ID
-	comment.TaskID = taskId
+	comment.TaskID = task.ID
+
+	// Replies must answer a comment of the same task
+	if comment.ParentID != nil {
+		var parent models.Comment
+		if err := config.DB.First(&parent, "id = ? AND task_id = ?", *comment.ParentID, task.ID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Parent comment must be a comment of this task"})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve parent comment"})
+		}
+	}
 
-	// Create the comment
-	if err := config.DB.Create(&comment).Error; err != nil {
+	mentioned, err := resolveMentions(comment.Content, task)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to resolve mentions"})
+	}
+
+	// Create the comment with its mentions, make the commenter a watcher and let the
+	// mentioned users and the watchers know
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Mentions").Create(&comment).Error; err != nil {
+			return err
+		}
+		added, err := setCommentMentions(tx, &comment, mentioned)
+		if err != nil {
+			return err
+		}
+		if err := addWatchers(tx, task.ID, user.ID); err != nil {
+			return err
+		}
+		return events.Publish(tx, models.CommentAdded, task, user.ID, models.CommentEvent{CommentID: comment.ID, Mentioned: added})
+	})
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create comment"})
 	}
+	events.Dispatch(task.ID)
 
-	response := models.FormatCommentResponse(comment)
+	response := renderComment(c, models.FormatCommentResponse(comment))
 
 	return c.Status(fiber.StatusCreated).JSON(response)
 }
@@ -46,15 +90,23 @@ func UpdateComment(c *fiber.Ctx) error {
 	commentID := c.Params("id")
 
 	var comment models.Comment
-	if err := config.DB.First(&comment, "id = ?", commentID).Error; err != nil {
+	if err := config.DB.Preload("Reactions.User").First(&comment, "id = ?", commentID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Comment not found"})
 		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve comment"})
 	}
 
-	// Check if the comment belongs to the authenticated user
-	if !utils.HasPermission(comment.CreatedBy, user.ID) {
+	var task models.Task
+	if err := config.DB.First(&task, "id = ?", comment.TaskID).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve task"})
+	}
+	if !canViewTask(c, task) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Comment not found"})
+	}
+
+	// Check if the comment belongs to the authenticated user or the user can moderate comments
+	if !utils.HasPermission(GetRole(c), utils.CommentModerate, comment.CreatedBy, user.ID) {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "You are not authorized to update this comment"})
 	}
 
@@ -64,12 +116,29 @@ func UpdateComment(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
 	}
 
-	// Update the comment
-	if err := config.DB.Model(&comment).Updates(updatedComment).Error; err != nil {
+	mentioned, err := resolveMentions(updatedComment.Content, task)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to resolve mentions"})
+	}
+
+	// Update the comment and its mentions, only users mentioned by this edit are notified
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&comment).Update("content", updatedComment.Content).Error; err != nil {
+			return err
+		}
+		comment.Content = updatedComment.Content
+		added, err := setCommentMentions(tx, &comment, mentioned)
+		if err != nil || len(added) == 0 {
+			return err
+		}
+		return events.Publish(tx, models.CommentEdited, task, user.ID, models.CommentEvent{CommentID: comment.ID, Mentioned: added})
+	})
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update comment"})
 	}
+	events.Dispatch(task.ID)
 
-	response := models.FormatCommentResponse(comment)
+	response := renderComment(c, models.FormatCommentResponse(comment))
 
 	return c.Status(fiber.StatusOK).JSON(response)
 }
@@ -85,16 +154,72 @@ func DeleteComment(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve comment"})
 	}
 
-	// Check if the comment belongs to the authenticated user
+	if visible, err := canViewTaskID(c, comment.TaskID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve task"})
+	} else if !visible {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Comment not found"})
+	}
+
+	// Check if the comment belongs to the authenticated user or the user can moderate comments
 	user := GetUserByID(c)
-	if !utils.HasPermission(comment.CreatedBy, user.ID) {
+	if !utils.HasPermission(GetRole(c), utils.CommentModerate, comment.CreatedBy, user.ID) {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "You are not authorized to delete this comment"})
 	}
 
-	// Delete the comment
+	// Delete the comment with its mentions, reactions and attachments, its replies move up to its parent
+	if err := config.DB.Where("comment_id = ?", comment.ID).Delete(&models.CommentMention{}).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete mentions"})
+	}
+	if err := config.DB.Where("comment_id = ?", comment.ID).Delete(&models.CommentReaction{}).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete reactions"})
+	}
+	if err := config.DB.Model(&models.Comment{}).Where("parent_id = ?", comment.ID).Update("parent_id", comment.ParentID).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to move replies"})
+	}
+	attachmentKeys, err := deleteAttachments(config.DB, "comment_id = ?", comment.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete attachments"})
+	}
 	if err := config.DB.Delete(&comment).Error; err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete comment"})
 	}
+	removeFiles(attachmentKeys...)
 
 	return c.Status(fiber.StatusOK).SendString("Comment deleted")
 }
+
+func commentCursor(comment models.Comment) utils.Cursor {
+	return utils.Cursor{Time: comment.UpdatedAt, ID: comment.ID}
+}
+
+// commentThreads nests replies under the comment they answer, keeping the given order within each thread
+func commentThreads(comments []models.Comment) []models.CommentResponse {
+	ids := map[string]bool{}
+	replies := map[string][]models.Comment{}
+	for _, comment := range comments {
+		ids[comment.ID] = true
+	}
+	var roots []models.Comment
+	for _, comment := range comments {
+		if comment.ParentID != nil && ids[*comment.ParentID] {
+			replies[*comment.ParentID] = append(replies[*comment.ParentID], comment)
+		} else {
+			roots = append(roots, comment)
+		}
+	}
+
+	var thread func(comment models.Comment) models.CommentResponse
+	thread = func(comment models.Comment) models.CommentResponse {
+		response := models.FormatCommentResponse(comment)
+		for _, reply := range replies[comment.ID] {
+			response.Replies = append(response.Replies, thread(reply))
+		}
+		return response
+	}
+
+	response := []models.CommentResponse{}
+	for _, comment := range roots {
+		response = append(response, thread(comment))
+	}
+	return response
+}
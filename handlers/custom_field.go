@@ -0,0 +1,341 @@
+package handlers
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"task-management-api/config"
+	"task-management-api/models"
+	"task-management-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type CustomFieldRequest struct {
+	Key      string   `json:"key"`
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Options  []string `json:"options"`
+	Required *bool    `json:"required"`
+	Position *int     `json:"position"`
+}
+
+// keys end up in JSON paths of filter expressions, so they are kept to a safe alphabet
+var customFieldKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,39}$`)
+
+var customFieldTypes = []string{models.FieldText, models.FieldNumber, models.FieldDate, models.FieldSelect, models.FieldMultiSelect, models.FieldUser}
+
+// GetCustomFields lists the custom fields of a project
+func GetCustomFields(c *fiber.Ctx) error {
+	project, ok := getProjectForMember(c)
+	if !ok {
+		return nil
+	}
+
+	fields, err := getCustomFields(project.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve custom fields"})
+	}
+
+	return c.JSON(fields)
+}
+
+func CreateCustomField(c *fiber.Ctx) error {
+	project, ok := getProjectForOwner(c)
+	if !ok {
+		return nil
+	}
+
+	var req CustomFieldRequest
+	if err := c.BodyParser(&req); err != nil || req.Name == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	if !customFieldKeyPattern.MatchString(req.Key) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Key must start with a letter and contain only lowercase letters, digits and underscores"})
+	}
+	if !slices.Contains(customFieldTypes, req.Type) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Type must be one of: " + strings.Join(customFieldTypes, ", ")})
+	}
+
+	field := models.CustomField{
+		ProjectID: project.ID,
+		Key:       req.Key,
+		Name:      req.Name,
+		Type:      req.Type,
+		Options:   req.Options,
+	}
+	if req.Required != nil {
+		field.Required = *req.Required
+	}
+	if req.Position != nil {
+		field.Position = *req.Position
+	}
+	if err := validateFieldOptions(field); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var count int64
+	config.DB.Model(&models.CustomField{}).Where("project_id = ? AND key = ?", project.ID, field.Key).Count(&count)
+	if count > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Custom field key is already in use"})
+	}
+
+	if err := config.DB.Create(&field).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create custom field"})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(field)
+}
+
+// UpdateCustomField changes the name, options, required flag or position of a field,
+// the key and type stay fixed so stored values keep their meaning
+func UpdateCustomField(c *fiber.Ctx) error {
+	project, ok := getProjectForOwner(c)
+	if !ok {
+		return nil
+	}
+
+	var field models.CustomField
+	if err := config.DB.First(&field, "id = ? AND project_id = ?", c.Params("fieldId"), project.ID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Custom field not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve custom field"})
+	}
+
+	var req CustomFieldRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	if (req.Key != "" && req.Key != field.Key) || (req.Type != "" && req.Type != field.Type) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "The key and type of a custom field cannot be changed"})
+	}
+
+	if req.Name != "" {
+		field.Name = req.Name
+	}
+	if req.Options != nil {
+		field.Options = req.Options
+	}
+	if req.Required != nil {
+		field.Required = *req.Required
+	}
+	if req.Position != nil {
+		field.Position = *req.Position
+	}
+	if err := validateFieldOptions(field); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := config.DB.Model(&field).Select("name", "options", "required", "position", "updated_at").Updates(&field).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update custom field"})
+	}
+
+	return c.JSON(field)
+}
+
+// DeleteCustomField removes a field and its values from the project's tasks
+func DeleteCustomField(c *fiber.Ctx) error {
+	project, ok := getProjectForOwner(c)
+	if !ok {
+		return nil
+	}
+
+	var field models.CustomField
+	if err := config.DB.First(&field, "id = ? AND project_id = ?", c.Params("fieldId"), project.ID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Custom field not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve custom field"})
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("UPDATE tasks SET custom_fields = custom_fields - ? WHERE project_id = ?", field.Key, project.ID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&field).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete custom field"})
+	}
+
+	return c.Status(fiber.StatusOK).SendString("Custom field deleted")
+}
+
+func getCustomFields(projectID string) ([]models.CustomField, error) {
+	var fields []models.CustomField
+	err := config.DB.Where("project_id = ?", projectID).Order("position, name").Find(&fields).Error
+	return fields, err
+}
+
+// select fields need choices, other types take none
+func validateFieldOptions(field models.CustomField) error {
+	if field.Type != models.FieldSelect && field.Type != models.FieldMultiSelect {
+		if len(field.Options) > 0 {
+			return fmt.Errorf("Options are only allowed for select fields")
+		}
+		return nil
+	}
+	if len(field.Options) == 0 {
+		return fmt.Errorf("Select fields need at least one option")
+	}
+	for i, option := range field.Options {
+		if option == "" || slices.Contains(field.Options[:i], option) {
+			return fmt.Errorf("Options must be unique and not empty")
+		}
+	}
+	return nil
+}
+
+// validateCustomFields applies changes to the current custom field values of a task.
+// A null value removes the field. Required fields are enforced when creating and when removed.
+// It returns the new values and the problems keyed by custom_fields.<key>.
+func validateCustomFields(projectID *string, current map[string]interface{}, changes map[string]interface{}, creating bool) (map[string]interface{}, map[string]string, error) {
+	problems := map[string]string{}
+	values := map[string]interface{}{}
+	maps.Copy(values, current)
+
+	if projectID == nil {
+		if len(changes) > 0 {
+			problems["custom_fields"] = "are only available on project tasks"
+		}
+		return values, problems, nil
+	}
+
+	fields, err := getCustomFields(*projectID)
+	if err != nil {
+		return nil, nil, err
+	}
+	byKey := map[string]models.CustomField{}
+	for _, field := range fields {
+		byKey[field.Key] = field
+	}
+
+	for key, value := range changes {
+		field, ok := byKey[key]
+		if !ok {
+			problems["custom_fields."+key] = "is not a field of this project"
+			continue
+		}
+		if value == nil {
+			if field.Required {
+				problems["custom_fields."+key] = "is required"
+			}
+			delete(values, key)
+			continue
+		}
+
+		normalized, err := normalizeCustomValue(field, value)
+		if err != nil {
+			problems["custom_fields."+key] = err.Error()
+			continue
+		}
+		values[key] = normalized
+	}
+
+	if creating {
+		for _, field := range fields {
+			if _, ok := values[field.Key]; field.Required && !ok {
+				problems["custom_fields."+field.Key] = "is required"
+			}
+		}
+	}
+
+	return values, problems, nil
+}
+
+// normalizeCustomValue checks a value against the field type, dates are stored as 2006-01-02
+func normalizeCustomValue(field models.CustomField, value interface{}) (interface{}, error) {
+	switch field.Type {
+	case models.FieldNumber:
+		if number, ok := value.(float64); ok {
+			return number, nil
+		}
+		return nil, fmt.Errorf("must be a number")
+	case models.FieldDate:
+		if s, ok := value.(string); ok {
+			if t, err := utils.ParseTime(s); err == nil {
+				return t.Format("2006-01-02"), nil
+			}
+		}
+		return nil, fmt.Errorf("must be a date (2006-01-02)")
+	case models.FieldSelect:
+		if s, ok := value.(string); ok && slices.Contains(field.Options, s) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("must be one of: %s", strings.Join(field.Options, ", "))
+	case models.FieldMultiSelect:
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("must be a list of: %s", strings.Join(field.Options, ", "))
+		}
+		selected := []string{}
+		for _, item := range items {
+			s, ok := item.(string)
+			if !ok || !slices.Contains(field.Options, s) {
+				return nil, fmt.Errorf("must be a list of: %s", strings.Join(field.Options, ", "))
+			}
+			if !slices.Contains(selected, s) {
+				selected = append(selected, s)
+			}
+		}
+		return selected, nil
+	case models.FieldUser:
+		if s, ok := value.(string); ok && validateAssignee(s, nil) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("must be a valid user ID")
+	default:
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+		return nil, fmt.Errorf("must be text")
+	}
+}
+
+// customFilterFields exposes custom fields as cf.<key> in where= and sort=.
+// Without a project, keys defined with different types in different projects are left out.
+func customFilterFields(projectID *string) (map[string]utils.FilterField, error) {
+	query := config.DB.Model(&models.CustomField{})
+	if projectID != nil {
+		query = query.Where("project_id = ?", *projectID)
+	}
+
+	var fields []models.CustomField
+	if err := query.Find(&fields).Error; err != nil {
+		return nil, err
+	}
+
+	types := map[string]string{}
+	conflicting := map[string]bool{}
+	for _, field := range fields {
+		if t, ok := types[field.Key]; ok && t != field.Type {
+			conflicting[field.Key] = true
+		}
+		types[field.Key] = field.Type
+	}
+
+	filterFields := map[string]utils.FilterField{}
+	for key, fieldType := range types {
+		if conflicting[key] {
+			continue
+		}
+		value := "tasks.custom_fields->>'" + key + "'"
+		switch fieldType {
+		case models.FieldNumber:
+			filterFields["cf."+key] = utils.FilterField{Column: "(" + value + ")::numeric", Type: utils.NumberField}
+		case models.FieldDate:
+			filterFields["cf."+key] = utils.FilterField{Column: "(" + value + ")::date", Type: utils.TimeField}
+		case models.FieldUser:
+			filterFields["cf."+key] = utils.FilterField{Column: "(" + value + ")::uuid", Type: utils.UserField}
+		default:
+			// multi-select values compare against their JSON text, e.g. cf.env ~ prod
+			filterFields["cf."+key] = utils.FilterField{Column: value, Type: utils.TextField}
+		}
+	}
+
+	return filterFields, nil
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"strconv"
+	"task-management-api/config"
+	"task-management-api/events"
+	"task-management-api/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// SubscribeEvents registers the in-process subscribers of the domain events
+func SubscribeEvents() {
+	events.Subscribe("history", recordHistory, models.TaskUpdated)
+	events.Subscribe("notifications", sendNotifications, models.TaskAssigned, models.CommentAdded, models.CommentEdited)
+	events.Subscribe("webhooks", queueWebhooks, models.TaskCreated, models.TaskUpdated, models.TaskDeleted, models.CommentAdded, models.HistoryRecorded)
+}
+
+// publishTaskEvent publishes TaskCreated, TaskUpdated or TaskDeleted with the given state of the task
+func publishTaskEvent(tx *gorm.DB, eventType string, task models.Task, actorID string, changes map[string]map[string]string) error {
+	return events.Publish(tx, eventType, task, actorID, models.TaskEvent{Task: models.FormatTaskResponse(task), Changes: changes})
+}
+
+// publishTaskUpdated publishes TaskUpdated with the task as it is stored in tx after the update
+func publishTaskUpdated(tx *gorm.DB, taskID string, actorID string, changes map[string]map[string]string) error {
+	var task models.Task
+	if err := tx.Preload("Labels").Preload("CoAssignees").First(&task, "id = ?", taskID).Error; err != nil {
+		return err
+	}
+	return publishTaskEvent(tx, models.TaskUpdated, task, actorID, changes)
+}
+
+// GetFailedEvents lists the events that ran out of attempts, newest first, with the error of the last one
+func GetFailedEvents(c *fiber.Ctx) error {
+	var failed []models.OutboxEvent
+	if err := config.DB.Where("failed_at IS NOT NULL").Order("id DESC").Limit(100).Find(&failed).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve events"})
+	}
+
+	response := []models.OutboxEventResponse{}
+	for _, event := range failed {
+		response = append(response, models.FormatOutboxEventResponse(event))
+	}
+
+	return c.JSON(response)
+}
+
+// RetryEvent hands a failed event to the subscribers that have not handled it yet, once the cause is fixed
+func RetryEvent(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid event ID"})
+	}
+
+	if err := events.Retry(id); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Failed event not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retry event"})
+	}
+
+	var event models.OutboxEvent
+	if err := config.DB.First(&event, "id = ?", id).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve event"})
+	}
+
+	return c.JSON(models.FormatOutboxEventResponse(event))
+}
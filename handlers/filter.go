@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"task-management-api/config"
+	"task-management-api/models"
+	"task-management-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+// Sort expressions ranking priority and severity by urgency instead of alphabetically
+const (
+	priorityRank = "array_position(ARRAY['LOWEST','LOW','MEDIUM','HIGH','HIGHEST']::varchar[], tasks.priority)"
+	severityRank = "array_position(ARRAY['TRIVIAL','MINOR','MAJOR','CRITICAL','BLOCKER']::varchar[], tasks.severity)"
+)
+
+// Fields usable in the where= and sort= parameters of task listings
+var taskFilterFields = map[string]utils.FilterField{
+	"id":          {Column: "tasks.id", Type: utils.IDField},
+	"key":         {Column: "tasks.key", Type: utils.EnumField},
+	"type":        {Column: "tasks.type", Type: utils.EnumField},
+	"title":       {Column: "tasks.title", Type: utils.TextField},
+	"description": {Column: "tasks.description", Type: utils.TextField},
+	"status":      {Column: "tasks.status", Type: utils.EnumField},
+	"priority":    {Column: "tasks.priority", Type: utils.EnumField, Order: priorityRank},
+	"severity":    {Column: "tasks.severity", Type: utils.EnumField, Order: severityRank},
+	"assignee":    {Column: "tasks.assignee", Type: utils.UserField},
+	"created_by":  {Column: "tasks.created_by", Type: utils.UserField},
+	"updated_by":  {Column: "tasks.updated_by", Type: utils.UserField},
+	"created_at":  {Column: "tasks.created_at", Type: utils.TimeField},
+	"updated_at":  {Column: "tasks.updated_at", Type: utils.TimeField},
+	"start_date":  {Column: "tasks.start_date", Type: utils.TimeField},
+	"due_date":    {Column: "tasks.due_date", Type: utils.TimeField},
+	"estimate":    {Column: "tasks.estimate", Type: utils.NumberField},
+	"sprint":      {Column: "tasks.sprint_id", Type: utils.IDField},
+}
+
+// taskFilterFromQuery reads the task listing parameters of the request
+func taskFilterFromQuery(c *fiber.Ctx) models.TaskFilter {
+	return models.TaskFilter{
+		Project:   c.Query("project", ""),
+		Title:     c.Query("title", ""),
+		Status:    c.Query("status", ""),
+		Assignee:  c.Query("assignee", ""),
+		CreatedBy: c.Query("createdBy", ""),
+		Overdue:   c.Query("overdue", ""),
+		DueBefore: c.Query("due_before", ""),
+		DueAfter:  c.Query("due_after", ""),
+		Label:     c.Query("label", ""),
+		LabelAny:  c.Query("label_any", ""),
+		Watching:  c.Query("watching", ""),
+		Where:     c.Query("where", ""),
+		Sort:      c.Query("sort", ""),
+	}
+}
+
+// resolveTaskFilter combines the request parameters with the saved filter given by filter=,
+// parameters sent with the request take precedence.
+// It returns the HTTP status to respond with when the saved filter cannot be used.
+func resolveTaskFilter(c *fiber.Ctx) (models.TaskFilter, int, error) {
+	filter := taskFilterFromQuery(c)
+
+	filterID := c.Query("filter", "")
+	if filterID == "" {
+		return filter, 0, nil
+	}
+
+	saved, status, err := getVisibleSavedFilter(c, filterID)
+	if err != nil {
+		return filter, status, err
+	}
+
+	var criteria models.TaskFilter
+	if err := json.Unmarshal([]byte(saved.Criteria), &criteria); err != nil {
+		return filter, fiber.StatusInternalServerError, errors.New("Failed to read saved filter")
+	}
+
+	return filter.Merge(criteria), 0, nil
+}
+
+// validateTaskFilter checks the parameters and expressions without running them,
+// with the custom fields of projectID (or of every project when nil)
+func validateTaskFilter(filter models.TaskFilter, projectID *string) error {
+	if filter.Overdue != "" {
+		if _, err := strconv.ParseBool(filter.Overdue); err != nil {
+			return errors.New("overdue must be true or false")
+		}
+	}
+	if filter.DueBefore != "" {
+		if _, err := utils.ParseTime(filter.DueBefore); err != nil {
+			return errors.New("due_before must be a date (2006-01-02) or RFC 3339 time")
+		}
+	}
+	if filter.DueAfter != "" {
+		if _, err := utils.ParseTime(filter.DueAfter); err != nil {
+			return errors.New("due_after must be a date (2006-01-02) or RFC 3339 time")
+		}
+	}
+	if filter.Watching != "" && filter.Watching != "me" && !utils.IsUUID(filter.Watching) {
+		return errors.New("watching must be a user ID or me")
+	}
+	fields, err := filterFieldsFor(filter, projectID)
+	if err != nil {
+		return err
+	}
+	if filter.Where != "" {
+		// any user ID will do to check expressions that use "me"
+		if _, _, err := utils.ParseFilter(filter.Where, fields, "00000000-0000-0000-0000-000000000000"); err != nil {
+			return errors.New("Invalid where: " + err.Error())
+		}
+	}
+	if filter.Sort != "" {
+		if _, err := utils.ParseSort(filter.Sort, fields); err != nil {
+			return errors.New("Invalid sort: " + err.Error())
+		}
+	}
+	return nil
+}
+
+// applyTaskFilter applies the simple filters (project, status, assignee, createdBy, title),
+// the where= expression and the sort= order to a task query.
+// projectID is the project the query is scoped to, if any; its custom fields can be used as cf.<key>.
+func applyTaskFilter(c *fiber.Ctx, query *gorm.DB, filter models.TaskFilter, projectID *string) (*gorm.DB, error) {
+	// on the task list, project=KEY picks the custom fields instead
+	if projectID == nil && filter.Project != "" {
+		var project models.Project
+		if err := config.DB.Select("id").Where("key = ?", strings.ToUpper(filter.Project)).Limit(1).Find(&project).Error; err != nil {
+			return nil, errors.New("Failed to retrieve project")
+		}
+		if project.ID != "" {
+			projectID = &project.ID
+		}
+	}
+
+	if err := validateTaskFilter(filter, projectID); err != nil {
+		return nil, err
+	}
+
+	// Apply filters based on query params
+	if filter.Project != "" {
+		query = query.Where("tasks.project_id = (?)", config.DB.Model(&models.Project{}).Select("id").Where("key = ?", strings.ToUpper(filter.Project)))
+	}
+	if filter.Status != "" {
+		query = query.Where("tasks.status = ?", filter.Status)
+	}
+	if filter.CreatedBy != "" {
+		query = query.Where("tasks.created_by = ?", filter.CreatedBy)
+	}
+	if filter.Title != "" {
+		query = query.Where("tasks.title ILIKE ?", "%"+filter.Title+"%")
+	}
+	if filter.Assignee != "" {
+		query = query.Where("tasks.assignee = ?", filter.Assignee)
+	}
+	if filter.Overdue != "" {
+		overdue, _ := strconv.ParseBool(filter.Overdue)
+		query = query.Where("tasks.overdue = ?", overdue)
+	}
+	if filter.DueBefore != "" {
+		dueBefore, _ := utils.ParseTime(filter.DueBefore)
+		query = query.Where("tasks.due_date < ?", dueBefore)
+	}
+	if filter.DueAfter != "" {
+		dueAfter, _ := utils.ParseTime(filter.DueAfter)
+		query = query.Where("tasks.due_date > ?", dueAfter)
+	}
+
+	// label=bug,ui needs both labels, label_any=bug,ui either of them
+	if names := labelFilterNames(filter.Label); len(names) > 0 {
+		labelled := config.DB.Table("task_labels").Select("task_labels.task_id").
+			Joins("JOIN labels ON labels.id = task_labels.label_id").
+			Where("lower(labels.name) IN ?", names).
+			Group("task_labels.task_id").
+			Having("count(DISTINCT lower(labels.name)) = ?", len(names))
+		query = query.Where("tasks.id IN (?)", labelled)
+	}
+	if names := labelFilterNames(filter.LabelAny); len(names) > 0 {
+		labelled := config.DB.Table("task_labels").Select("task_labels.task_id").
+			Joins("JOIN labels ON labels.id = task_labels.label_id").
+			Where("lower(labels.name) IN ?", names)
+		query = query.Where("tasks.id IN (?)", labelled)
+	}
+
+	// watching=me lists the tasks the user follows, user managers can look at what others follow
+	if filter.Watching != "" {
+		watcher := filter.Watching
+		userID := currentUserID(c)
+		if userID == "" {
+			return nil, errors.New("watching requires authentication")
+		}
+		if watcher == "me" {
+			watcher = userID
+		}
+		if watcher != userID && !utils.RoleHasPermission(GetRole(c), utils.UserManage) {
+			return nil, errors.New("watching must be me")
+		}
+		query = query.Where("tasks.id IN (?)", config.DB.Model(&models.TaskWatcher{}).Select("task_id").Where("user_id = ?", watcher))
+	}
+
+	fields, err := filterFieldsFor(filter, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	// e.g. where=status in (TODO,IN_PROGRESS) and assignee = me
+	if filter.Where != "" {
+		condition, args, err := utils.ParseFilter(filter.Where, fields, currentUserID(c))
+		if err != nil {
+			return nil, errors.New("Invalid where: " + err.Error())
+		}
+		query = query.Where(condition, args...)
+	}
+
+	// e.g. sort=-updated_at,title
+	if filter.Sort != "" {
+		order, err := utils.ParseSort(filter.Sort, fields)
+		if err != nil {
+			return nil, errors.New("Invalid sort: " + err.Error())
+		}
+		query = query.Order(order)
+	}
+
+	return query, nil
+}
+
+// filterFieldsFor adds the custom fields (cf.<key>) to the task fields when the filter uses them
+func filterFieldsFor(filter models.TaskFilter, projectID *string) (map[string]utils.FilterField, error) {
+	if !strings.Contains(filter.Where+filter.Sort, "cf.") {
+		return taskFilterFields, nil
+	}
+
+	custom, err := customFilterFields(projectID)
+	if err != nil {
+		return nil, errors.New("Failed to retrieve custom fields")
+	}
+
+	fields := maps.Clone(taskFilterFields)
+	maps.Copy(fields, custom)
+	return fields, nil
+}
+
+// labelFilterNames splits a comma separated list of label names, ignoring case and duplicates
+func labelFilterNames(value string) []string {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "" && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// currentUserID returns the authenticated user on routes with optional authentication
+func currentUserID(c *fiber.Ctx) string {
+	claims, ok := c.Locals("user").(jwt.MapClaims)
+	if !ok {
+		return ""
+	}
+	userID, _ := claims["user_id"].(string)
+	return userID
+}
@@ -2,27 +2,78 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
 	"task-management-api/config"
+	"task-management-api/events"
 	"task-management-api/models"
+	"task-management-api/utils"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 )
 
-func AddHistory(taskID string, task models.Task) error {
+// GetTaskHistory lists the history of a task, newest first
+func GetTaskHistory(c *fiber.Ctx) error {
+	task, ok := getVisibleTask(c)
+	if !ok {
+		return nil
+	}
+
+	query := config.DB.Model(&models.History{}).Preload("ChangedUser").Where("task_id = ?", task.ID)
+	if !isCursorMode(c) {
+		query = query.Order("changed_at DESC, id DESC")
+	}
+
+	return paginate(c, query, "histories.changed_at", "histories.id", historyCursor, formatHistory)
+}
+
+// taskChanges returns the fields of task that differ from the stored task, as from/to pairs.
+// cleared lists the nullable fields (start_date, due_date, estimate, severity) being removed.
+// Labels are compared when task.LabelIDs is set. They are recorded in history by the
+// history subscriber of TaskUpdated.
+func taskChanges(db *gorm.DB, taskID string, task models.Task, cleared ...string) (map[string]map[string]string, error) {
 	var oldTask models.Task
-	if err := config.DB.First(&oldTask, "id = ?", taskID).Error; err != nil {
-		return err
+	if err := db.First(&oldTask, "id = ?", taskID).Error; err != nil {
+		return nil, err
 	}
 
 	changes := make(map[string]map[string]string)
 
+	if task.Type != "" && oldTask.Type != task.Type {
+		changes["type"] = map[string]string{"from": string(oldTask.Type), "to": string(task.Type)}
+	}
 	if task.Title != "" && oldTask.Title != task.Title {
 		changes["title"] = map[string]string{"from": oldTask.Title, "to": task.Title}
 	}
 	if task.Description != "" && oldTask.Description != task.Description {
 		changes["description"] = map[string]string{"from": oldTask.Description, "to": task.Description}
 	}
+	if task.StepsToReproduce != "" && oldTask.StepsToReproduce != task.StepsToReproduce {
+		changes["steps_to_reproduce"] = map[string]string{"from": oldTask.StepsToReproduce, "to": task.StepsToReproduce}
+	}
 	if task.Status != "" && oldTask.Status != task.Status {
 		changes["status"] = map[string]string{"from": string(oldTask.Status), "to": string(task.Status)}
 	}
+	if task.Priority != "" && oldTask.Priority != task.Priority {
+		changes["priority"] = map[string]string{"from": string(oldTask.Priority), "to": string(task.Priority)}
+	}
+	if task.Severity != nil || slices.Contains(cleared, "severity") {
+		var from, to string
+		if oldTask.Severity != nil {
+			from = string(*oldTask.Severity)
+		}
+		if task.Severity != nil {
+			to = string(*task.Severity)
+		}
+		if from != to {
+			changes["severity"] = map[string]string{"from": from, "to": to}
+		}
+	}
 	if task.Assignee != nil && oldTask.Assignee != task.Assignee {
 		var oldAssignee string
 		var newAssignee string
@@ -35,28 +86,168 @@ func AddHistory(taskID string, task models.Task) error {
 		changes["assignee"] = map[string]string{"from": oldAssignee, "to": newAssignee}
 	}
 
-	// If no changes, return nil
-	if len(changes) == 0 {
+	if task.ParentID != nil {
+		var oldParent string
+		if oldTask.ParentID != nil {
+			oldParent = *oldTask.ParentID
+		}
+		if oldParent != *task.ParentID {
+			changes["parent"] = map[string]string{"from": oldParent, "to": *task.ParentID}
+		}
+	}
+
+	if task.StartDate != nil || slices.Contains(cleared, "start_date") {
+		if from, to := formatDate(oldTask.StartDate), formatDate(task.StartDate); from != to {
+			changes["start_date"] = map[string]string{"from": from, "to": to}
+		}
+	}
+	if task.DueDate != nil || slices.Contains(cleared, "due_date") {
+		if from, to := formatDate(oldTask.DueDate), formatDate(task.DueDate); from != to {
+			changes["due_date"] = map[string]string{"from": from, "to": to}
+		}
+	}
+	if task.Estimate != nil || slices.Contains(cleared, "estimate") {
+		if from, to := formatInt(oldTask.Estimate), formatInt(task.Estimate); from != to {
+			changes["estimate"] = map[string]string{"from": from, "to": to}
+		}
+	}
+
+	// custom fields are recorded as cf.<key>
+	if task.CustomFields != nil {
+		keys := slices.Collect(maps.Keys(task.CustomFields))
+		for key := range oldTask.CustomFields {
+			if !slices.Contains(keys, key) {
+				keys = append(keys, key)
+			}
+		}
+		for _, key := range keys {
+			if from, to := formatCustomValue(oldTask.CustomFields[key]), formatCustomValue(task.CustomFields[key]); from != to {
+				changes["cf."+key] = map[string]string{"from": from, "to": to}
+			}
+		}
+	}
+
+	if task.AssigneeIDs != nil {
+		var coAssignees []string
+		if err := db.Model(&models.TaskAssignee{}).Where("task_id = ?", taskID).Order("created_at").Pluck("user_id", &coAssignees).Error; err != nil {
+			return nil, err
+		}
+		oldTask.CoAssignees = nil
+		for _, userID := range coAssignees {
+			oldTask.CoAssignees = append(oldTask.CoAssignees, models.TaskAssignee{UserID: userID})
+		}
+		if from, to := strings.Join(models.TaskAssigneeIDs(oldTask), ", "), strings.Join(task.AssigneeIDs, ", "); from != to {
+			changes["assignees"] = map[string]string{"from": from, "to": to}
+		}
+	}
+
+	if task.LabelIDs != nil {
+		from, err := labelNames(db.Model(&models.Label{}).Joins("JOIN task_labels ON task_labels.label_id = labels.id").Where("task_labels.task_id = ?", taskID))
+		if err != nil {
+			return nil, err
+		}
+		to, err := labelNames(db.Model(&models.Label{}).Where("id IN ?", task.LabelIDs))
+		if err != nil {
+			return nil, err
+		}
+		if from != to {
+			changes["labels"] = map[string]string{"from": from, "to": to}
+		}
+	}
+
+	return changes, nil
+}
+
+// labelNames joins the sorted label names selected by query
+func labelNames(query *gorm.DB) (string, error) {
+	var names []string
+	if err := query.Order("labels.name").Pluck("labels.name", &names).Error; err != nil {
+		return "", err
+	}
+	return strings.Join(names, ", "), nil
+}
+
+// formatCustomValue renders a custom field value, lists as comma separated text
+func formatCustomValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []string:
+		return strings.Join(v, ", ")
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = formatCustomValue(item)
+		}
+		return strings.Join(items, ", ")
+	}
+	return fmt.Sprint(value)
+}
+
+func formatDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func formatInt(i *int) string {
+	if i == nil {
+		return ""
+	}
+	return strconv.Itoa(*i)
+}
+
+func historyCursor(history models.History) utils.Cursor {
+	return utils.Cursor{Time: history.ChangedAt, ID: history.ID}
+}
+
+// formatHistory falls back to the raw record if its changes cannot be decoded
+func formatHistory(history models.History) interface{} {
+	response, err := models.FormatHistoryResponse(history)
+	if err != nil {
+		return history
+	}
+	return response
+}
+
+// recordHistory saves the changes of a TaskUpdated event in the task's history
+func recordHistory(tx *gorm.DB, event models.OutboxEvent) error {
+	var payload models.TaskEvent
+	if err := event.Decode(&payload); err != nil {
+		return err
+	}
+	if len(payload.Changes) == 0 {
+		return nil
+	}
+
+	// The history of a task goes with it, skip changes handled after the task was deleted
+	var count int64
+	if err := tx.Model(&models.Task{}).Where("id = ?", event.AggregateID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
 		return nil
 	}
 
-	// Convert changes map to JSON
-	changesJSON, err := json.Marshal(changes)
+	changesJSON, err := json.Marshal(payload.Changes)
 	if err != nil {
 		return err
 	}
 
-	// Create a TaskHistory record
 	history := models.History{
-		TaskID:    taskID,
-		ChangedBy: task.UpdatedBy,
+		TaskID:    event.AggregateID,
+		ChangedBy: event.ActorID,
 		Changes:   string(changesJSON),
+		ChangedAt: event.CreatedAt,
 	}
-
-	// Save the history
-	if err := config.DB.Create(&history).Error; err != nil {
+	if err := tx.Create(&history).Error; err != nil {
 		return err
 	}
 
-	return nil
+	return events.Publish(tx, models.HistoryRecorded, models.Task{ID: event.AggregateID, ProjectID: event.ProjectID}, event.ActorID, models.HistoryEvent{HistoryID: history.ID})
 }
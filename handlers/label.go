@@ -0,0 +1,407 @@
+package handlers
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+	"task-management-api/config"
+	"task-management-api/events"
+	"task-management-api/models"
+	"task-management-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type LabelRequest struct {
+	Name      string  `json:"name"`
+	Color     string  `json:"color"`
+	ProjectID *string `json:"project_id"`
+}
+
+type MergeLabelRequest struct {
+	Into string `json:"into"`
+}
+
+type TaskLabelRequest struct {
+	LabelID string `json:"label_id"`
+}
+
+var colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// GetAllLabels lists the global labels, plus the labels of the project given by project= to its members
+func GetAllLabels(c *fiber.Ctx) error {
+	query := config.DB.Order("name")
+
+	if projectKey := c.Query("project", ""); projectKey != "" {
+		var projects []models.Project
+		if err := config.DB.Select("id").Where("key = ?", strings.ToUpper(projectKey)).Limit(1).Find(&projects).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve project"})
+		}
+		if len(projects) == 0 || !isProjectMember(c, projects[0].ID, currentUserID(c)) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Project not found"})
+		}
+		query = query.Where("project_id IS NULL OR project_id = ?", projects[0].ID)
+	} else {
+		query = query.Where("project_id IS NULL")
+	}
+
+	var labels []models.Label
+	if err := query.Find(&labels).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve labels"})
+	}
+
+	return c.JSON(models.FormatLabelsResponse(labels))
+}
+
+func CreateLabel(c *fiber.Ctx) error {
+	user := GetUserByID(c)
+
+	var req LabelRequest
+	if err := c.BodyParser(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	if req.Color != "" && !colorPattern.MatchString(req.Color) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Color must be a hex color like #1d76db"})
+	}
+
+	// Project members can add project labels, global labels are managed by admins
+	if req.ProjectID != nil {
+		if !isProjectMember(c, *req.ProjectID, user.ID) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not a member of this project"})
+		}
+	} else if !utils.RoleHasPermission(GetRole(c), utils.LabelManage) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not authorized to create global labels"})
+	}
+
+	label := models.Label{
+		ProjectID: req.ProjectID,
+		Name:      strings.TrimSpace(req.Name),
+		Color:     req.Color,
+		CreatedBy: user.ID,
+	}
+	if label.Color == "" {
+		label.Color = "#888888"
+	}
+
+	if exists, err := labelNameTaken(label.ProjectID, label.Name, ""); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve labels"})
+	} else if exists {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Label already exists"})
+	}
+
+	if err := config.DB.Create(&label).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create label"})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.FormatLabelResponse(label))
+}
+
+// UpdateLabel renames or recolors a label
+func UpdateLabel(c *fiber.Ctx) error {
+	label, ok := getManagedLabel(c, c.Params("id"))
+	if !ok {
+		return nil
+	}
+
+	var req LabelRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	if req.Color != "" && !colorPattern.MatchString(req.Color) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Color must be a hex color like #1d76db"})
+	}
+
+	payload := models.Label{
+		Name:  strings.TrimSpace(req.Name),
+		Color: req.Color,
+	}
+	if payload.Name != "" {
+		if exists, err := labelNameTaken(label.ProjectID, payload.Name, label.ID); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve labels"})
+		} else if exists {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Label already exists"})
+		}
+	}
+
+	if err := config.DB.Model(&label).Updates(payload).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update label"})
+	}
+
+	return c.JSON(models.FormatLabelResponse(label))
+}
+
+// MergeLabel moves every task of a label to another label and deletes it
+func MergeLabel(c *fiber.Ctx) error {
+	label, ok := getManagedLabel(c, c.Params("id"))
+	if !ok {
+		return nil
+	}
+
+	var req MergeLabelRequest
+	if err := c.BodyParser(&req); err != nil || req.Into == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	if req.Into == label.ID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "A label cannot be merged into itself"})
+	}
+
+	var target models.Label
+	if err := config.DB.First(&target, "id = ?", req.Into).Error; err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Target label not found"})
+	}
+
+	// A project label can only go into a label of the same project or a global one
+	if target.ProjectID != nil && !sameProject(label.ProjectID, target.ProjectID) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Labels must belong to the same project"})
+	}
+
+	var taskIDs []string
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if taskIDs, err = relabelTasks(tx, label.ID, target.ID, GetUserByID(c).ID); err != nil {
+			return err
+		}
+		return tx.Delete(&label).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to merge label"})
+	}
+	dispatchAll(taskIDs)
+
+	return c.JSON(models.FormatLabelResponse(target))
+}
+
+func DeleteLabel(c *fiber.Ctx) error {
+	label, ok := getManagedLabel(c, c.Params("id"))
+	if !ok {
+		return nil
+	}
+
+	var taskIDs []string
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if taskIDs, err = relabelTasks(tx, label.ID, "", GetUserByID(c).ID); err != nil {
+			return err
+		}
+		return tx.Delete(&label).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete label"})
+	}
+	dispatchAll(taskIDs)
+
+	return c.Status(fiber.StatusOK).SendString("Label deleted")
+}
+
+func AddTaskLabel(c *fiber.Ctx) error {
+	var req TaskLabelRequest
+	if err := c.BodyParser(&req); err != nil || req.LabelID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	return changeTaskLabels(c, func(ids []string) []string {
+		if slices.Contains(ids, req.LabelID) {
+			return ids
+		}
+		return append(ids, req.LabelID)
+	})
+}
+
+func RemoveTaskLabel(c *fiber.Ctx) error {
+	labelID := c.Params("labelId")
+
+	return changeTaskLabels(c, func(ids []string) []string {
+		var kept []string
+		for _, id := range ids {
+			if id != labelID {
+				kept = append(kept, id)
+			}
+		}
+		return kept
+	})
+}
+
+// changeTaskLabels applies change to the label IDs of the task in the :id param,
+// recording the change in the task history
+func changeTaskLabels(c *fiber.Ctx, change func([]string) []string) error {
+	user := GetUserByID(c)
+
+	var task models.Task
+	if err := config.DB.Preload("Labels").Where(taskIDCondition(c.Params("id"))).First(&task).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Task not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve task"})
+	}
+
+	if !canViewTask(c, task) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Task not found"})
+	}
+
+	if !canUpdateTask(c, task, user.ID) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not authorized to update this task"})
+	}
+
+	var ids []string
+	for _, label := range task.Labels {
+		ids = append(ids, label.ID)
+	}
+
+	labels, err := getTaskLabels(task, change(ids))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := setTaskLabels(task, labels, user.ID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update labels"})
+	}
+
+	return c.JSON(models.FormatLabelsResponse(labels))
+}
+
+// getTaskLabels loads the labels by ID, checking that they can be used on the task
+func getTaskLabels(task models.Task, labelIDs []string) ([]models.Label, error) {
+	labels := []models.Label{}
+	if len(labelIDs) == 0 {
+		return labels, nil
+	}
+
+	if err := config.DB.Where("id IN ?", labelIDs).Order("name").Find(&labels).Error; err != nil {
+		return nil, errors.New("Labels must be valid label IDs")
+	}
+	if len(labels) != len(labelIDs) {
+		return nil, errors.New("Labels must be valid label IDs")
+	}
+
+	for _, label := range labels {
+		if label.ProjectID != nil && !sameProject(label.ProjectID, task.ProjectID) {
+			return nil, errors.New("Label " + label.Name + " belongs to another project")
+		}
+	}
+
+	return labels, nil
+}
+
+// setTaskLabels replaces the labels of the task, publishing the change
+func setTaskLabels(task models.Task, labels []models.Label, userID string) error {
+	ids := []string{}
+	for _, label := range labels {
+		ids = append(ids, label.ID)
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		changes, err := taskChanges(tx, task.ID, models.Task{LabelIDs: ids})
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&task).Association("Labels").Replace(labels); err != nil {
+			return err
+		}
+		if len(changes) == 0 {
+			return nil
+		}
+		return publishTaskUpdated(tx, task.ID, userID, changes)
+	})
+	if err != nil {
+		return err
+	}
+	events.Dispatch(task.ID)
+
+	return nil
+}
+
+// relabelTasks takes the label off every task that has it, putting the into label on instead unless into
+// is empty, and publishes the labels change of each task. It returns the tasks to dispatch once tx commits.
+func relabelTasks(tx *gorm.DB, labelID string, into string, userID string) ([]string, error) {
+	var taskIDs []string
+	if err := tx.Table("task_labels").Where("label_id = ?", labelID).Order("task_id").Pluck("task_id", &taskIDs).Error; err != nil {
+		return nil, err
+	}
+
+	// The changes are worked out before the labels move
+	taskChangeSets := make([]map[string]map[string]string, len(taskIDs))
+	for i, taskID := range taskIDs {
+		var others []string
+		if err := tx.Table("task_labels").Where("task_id = ? AND label_id <> ?", taskID, labelID).Pluck("label_id", &others).Error; err != nil {
+			return nil, err
+		}
+		ids := append([]string{}, others...)
+		if into != "" && !slices.Contains(ids, into) {
+			ids = append(ids, into)
+		}
+
+		changes, err := taskChanges(tx, taskID, models.Task{LabelIDs: ids})
+		if err != nil {
+			return nil, err
+		}
+		taskChangeSets[i] = changes
+	}
+
+	if into != "" {
+		if err := tx.Exec(`INSERT INTO task_labels (task_id, label_id)
+			SELECT task_id, ? FROM task_labels WHERE label_id = ?
+			ON CONFLICT DO NOTHING`, into, labelID).Error; err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Exec("DELETE FROM task_labels WHERE label_id = ?", labelID).Error; err != nil {
+		return nil, err
+	}
+
+	for i, taskID := range taskIDs {
+		if len(taskChangeSets[i]) == 0 {
+			continue
+		}
+		if err := publishTaskUpdated(tx, taskID, userID, taskChangeSets[i]); err != nil {
+			return nil, err
+		}
+	}
+	return taskIDs, nil
+}
+
+// getManagedLabel loads a label the user can rename, merge or delete: project labels by the
+// project owner, global labels by label managers.
+// When ok is false the error response has already been written.
+func getManagedLabel(c *fiber.Ctx, labelID string) (label models.Label, ok bool) {
+	if err := config.DB.First(&label, "id = ?", labelID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Label not found"})
+			return label, false
+		}
+		c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve label"})
+		return label, false
+	}
+
+	allowed := utils.RoleHasPermission(GetRole(c), utils.LabelManage)
+	if !allowed && label.ProjectID != nil {
+		var project models.Project
+		if err := config.DB.First(&project, "id = ?", *label.ProjectID).Error; err == nil {
+			allowed = project.OwnerID == GetUserByID(c).ID
+		}
+	}
+	if !allowed {
+		c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not authorized to manage this label"})
+		return label, false
+	}
+
+	return label, true
+}
+
+// labelNameTaken checks case-insensitively for another label with the name in the same scope
+func labelNameTaken(projectID *string, name string, exceptID string) (bool, error) {
+	query := config.DB.Model(&models.Label{}).Where("lower(name) = lower(?)", name)
+	if projectID != nil {
+		query = query.Where("project_id = ?", *projectID)
+	} else {
+		query = query.Where("project_id IS NULL")
+	}
+	if exceptID != "" {
+		query = query.Where("id <> ?", exceptID)
+	}
+
+	var count int64
+	err := query.Count(&count).Error
+	return count > 0, err
+}
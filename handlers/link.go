@@ -0,0 +1,301 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"task-management-api/config"
+	"task-management-api/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type TaskLinkRequest struct {
+	Type   string `json:"type"`
+	TaskID string `json:"task_id"`
+}
+
+// GetTaskLinks lists the links of a task in both directions
+func GetTaskLinks(c *fiber.Ctx) error {
+	task, ok := getVisibleTask(c)
+	if !ok {
+		return nil
+	}
+
+	links, err := getTaskLinks(c, task.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve links"})
+	}
+
+	return c.JSON(links)
+}
+
+func CreateTaskLink(c *fiber.Ctx) error {
+	user := GetUserByID(c)
+
+	var task models.Task
+	if err := config.DB.Where(taskIDCondition(c.Params("id"))).First(&task).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Task not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve task"})
+	}
+
+	if !canViewTask(c, task) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Task not found"})
+	}
+
+	if !canUpdateTask(c, task, user.ID) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not authorized to update this task"})
+	}
+
+	var req TaskLinkRequest
+	if err := c.BodyParser(&req); err != nil || req.TaskID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	if !validateLinkType(req.Type) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Type must be one of: blocks, is_blocked_by, relates_to, duplicates"})
+	}
+
+	var other models.Task
+	if err := config.DB.Where(taskIDCondition(req.TaskID)).First(&other).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Linked task not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve linked task"})
+	}
+	if !canViewTask(c, other) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Linked task not found"})
+	}
+	if other.ID == task.ID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "A task cannot be linked to itself"})
+	}
+
+	// "is blocked by" is stored as the inverse "blocks" link
+	link := models.TaskLink{
+		SourceID:  task.ID,
+		TargetID:  other.ID,
+		Type:      req.Type,
+		CreatedBy: user.ID,
+	}
+	if req.Type == models.LinkIsBlockedBy {
+		link.SourceID, link.TargetID = other.ID, task.ID
+		link.Type = models.LinkBlocks
+	}
+
+	if link.Type == models.LinkBlocks {
+		cycle, err := createsBlockingCycle(link.SourceID, link.TargetID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to check blocking links"})
+		}
+		if cycle {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Link would create a blocking cycle"})
+		}
+	}
+
+	if err := config.DB.Create(&link).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) || strings.Contains(err.Error(), "duplicate key") {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Link already exists"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create link"})
+	}
+
+	link.Source, link.Target = task, other
+	if link.SourceID != task.ID {
+		link.Source, link.Target = other, task
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.FormatTaskLinkResponse(link, task.ID))
+}
+
+func DeleteTaskLink(c *fiber.Ctx) error {
+	user := GetUserByID(c)
+
+	var task models.Task
+	if err := config.DB.Where(taskIDCondition(c.Params("id"))).First(&task).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Task not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve task"})
+	}
+
+	if !canViewTask(c, task) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Task not found"})
+	}
+
+	if !canUpdateTask(c, task, user.ID) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not authorized to update this task"})
+	}
+
+	res := config.DB.Where("id = ? AND (source_id = ? OR target_id = ?)", c.Params("linkId"), task.ID, task.ID).Delete(&models.TaskLink{})
+	if res.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete link"})
+	}
+	if res.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Link not found"})
+	}
+
+	return c.Status(fiber.StatusOK).SendString("Link deleted")
+}
+
+func GetSubtasks(c *fiber.Ctx) error {
+	task, ok := getVisibleTask(c)
+	if !ok {
+		return nil
+	}
+
+	var subtasks []models.Task
+	if err := visibleTasks(c, config.DB.Preload("CreatedUser").Preload("UpdatedUser").Preload("AssigneeUser")).Where("parent_id = ?", task.ID).Find(&subtasks).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve subtasks"})
+	}
+
+	response := []models.TaskResponse{}
+	for _, subtask := range subtasks {
+		response = append(response, renderTask(c, models.FormatTaskResponse(subtask)))
+	}
+
+	return c.JSON(response)
+}
+
+// getTaskLinks lists the links of a task to the tasks the user can see
+func getTaskLinks(c *fiber.Ctx, taskID string) ([]models.TaskLinkResponse, error) {
+	visible := visibleTasks(c, config.DB.Model(&models.Task{}).Select("tasks.id"))
+
+	var links []models.TaskLink
+	if err := config.DB.Preload("Source").Preload("Target").
+		Where("(source_id = ? AND target_id IN (?)) OR (target_id = ? AND source_id IN (?))", taskID, visible, taskID, visible).
+		Order("created_at").Find(&links).Error; err != nil {
+		return nil, err
+	}
+
+	response := []models.TaskLinkResponse{}
+	for _, link := range links {
+		response = append(response, models.FormatTaskLinkResponse(link, taskID))
+	}
+	return response, nil
+}
+
+// return true if link type is one of the supported link types
+func validateLinkType(linkType string) bool {
+	return linkType == models.LinkBlocks || linkType == models.LinkIsBlockedBy || linkType == models.LinkRelatesTo || linkType == models.LinkDuplicates
+}
+
+// createsBlockingCycle reports whether source blocking target would close a cycle,
+// i.e. whether target already blocks source directly or transitively
+func createsBlockingCycle(sourceID string, targetID string) (bool, error) {
+	visited := map[string]bool{targetID: true}
+	frontier := []string{targetID}
+
+	for len(frontier) > 0 {
+		var next []string
+		if err := config.DB.Model(&models.TaskLink{}).Where("type = ? AND source_id IN ?", models.LinkBlocks, frontier).Pluck("target_id", &next).Error; err != nil {
+			return false, err
+		}
+
+		frontier = nil
+		for _, id := range next {
+			if id == sourceID {
+				return true, nil
+			}
+			if !visited[id] {
+				visited[id] = true
+				frontier = append(frontier, id)
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// validateParent checks that parentID can become the parent of the task:
+// it must exist in the same project and must not be the task or one of its subtasks
+func validateParent(task models.Task, parentID string) error {
+	var parent models.Task
+	if err := config.DB.First(&parent, "id = ?", parentID).Error; err != nil {
+		return errors.New("Parent must be a valid task ID")
+	}
+	if !sameProject(task.ProjectID, parent.ProjectID) {
+		return errors.New("Parent must belong to the same project")
+	}
+
+	// Walk up from the parent, reaching the task means it would become its own ancestor
+	for current := &parent; current != nil; {
+		if task.ID != "" && current.ID == task.ID {
+			return errors.New("Parent would create a cycle")
+		}
+		if current.ParentID == nil {
+			break
+		}
+		var next models.Task
+		if err := config.DB.First(&next, "id = ?", *current.ParentID).Error; err != nil {
+			break
+		}
+		current = &next
+	}
+
+	return nil
+}
+
+// incompleteDependencies lists the keys (or titles) of open blockers and open subtasks of the task
+func incompleteDependencies(taskID string) ([]string, error) {
+	var blockers []models.Task
+	blocking := config.DB.Model(&models.TaskLink{}).Select("source_id").Where("type = ? AND target_id = ?", models.LinkBlocks, taskID)
+	if err := config.DB.Where("id IN (?) OR parent_id = ?", blocking, taskID).Find(&blockers).Error; err != nil {
+		return nil, err
+	}
+
+	workflows := workflowCache{}
+	var open []string
+	for _, blocker := range blockers {
+		done, err := workflows.isDone(blocker)
+		if err != nil {
+			return nil, err
+		}
+		if !done {
+			name := blocker.Title
+			if blocker.Key != nil {
+				name = *blocker.Key
+			}
+			open = append(open, name)
+		}
+	}
+
+	return open, nil
+}
+
+// workflowCache avoids reloading the same workflow for tasks of one project
+type workflowCache map[string]models.Workflow
+
+func (w workflowCache) get(projectID *string) (models.Workflow, error) {
+	key := ""
+	if projectID != nil {
+		key = *projectID
+	}
+	if workflow, ok := w[key]; ok {
+		return workflow, nil
+	}
+
+	workflow, err := getWorkflow(projectID)
+	if err != nil {
+		return workflow, err
+	}
+	w[key] = workflow
+	return workflow, nil
+}
+
+// return true if the task is in a done state of its workflow
+func (w workflowCache) isDone(task models.Task) (bool, error) {
+	workflow, err := w.get(task.ProjectID)
+	if err != nil {
+		return false, err
+	}
+	state, ok := workflow.State(string(task.Status))
+	return ok && state.Category == models.CategoryDone, nil
+}
+
+func sameProject(a *string, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
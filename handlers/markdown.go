@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"task-management-api/models"
+	"task-management-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// wantsHTML reports whether the client asked for rendered Markdown with ?render=html
+func wantsHTML(c *fiber.Ctx) bool {
+	return c.Query("render") == "html"
+}
+
+func renderTask(c *fiber.Ctx, response models.TaskResponse) models.TaskResponse {
+	if wantsHTML(c) {
+		response.DescriptionHTML = utils.RenderMarkdown(response.Description)
+	}
+	return response
+}
+
+func renderComment(c *fiber.Ctx, response models.CommentResponse) models.CommentResponse {
+	if wantsHTML(c) {
+		response.ContentHTML = utils.RenderMarkdown(response.Content)
+		for i := range response.Replies {
+			response.Replies[i] = renderComment(c, response.Replies[i])
+		}
+	}
+	return response
+}
+
+func renderTaskDetails(c *fiber.Ctx, response models.TaskDetailsResponse) models.TaskDetailsResponse {
+	if !wantsHTML(c) {
+		return response
+	}
+	response.DescriptionHTML = utils.RenderMarkdown(response.Description)
+	for i := range response.Comments {
+		response.Comments[i] = renderComment(c, response.Comments[i])
+	}
+	return response
+}
+
+// taskFormatter and commentFormatter format paginated rows, rendered when asked for
+func taskFormatter(c *fiber.Ctx) func(models.Task) interface{} {
+	return func(task models.Task) interface{} {
+		return renderTask(c, models.FormatTaskResponse(task))
+	}
+}
+
+func commentFormatter(c *fiber.Ctx) func(models.Comment) interface{} {
+	return func(comment models.Comment) interface{} {
+		return renderComment(c, models.FormatCommentResponse(comment))
+	}
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"task-management-api/config"
+	"task-management-api/models"
+
+	"gorm.io/gorm"
+)
+
+// mentionPattern matches @alice@example.com or @alice, not the domain part of a plain email
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@.])@([\w.+-]+(?:@[\w-]+(?:\.[\w-]+)+)?)`)
+
+// parseMentions lists the distinct lowercase handles mentioned in content
+func parseMentions(content string) []string {
+	var handles []string
+	for _, match := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		handle := strings.ToLower(strings.TrimRight(match[1], "."))
+		if handle != "" && !slices.Contains(handles, handle) {
+			handles = append(handles, handle)
+		}
+	}
+	return handles
+}
+
+// resolveMentions finds the users mentioned in content on the task. A handle with a domain must match an email,
+// a bare handle the part of an email before @ when exactly one user has it. On project tasks only the
+// project members can be mentioned. Unknown handles are ignored.
+func resolveMentions(content string, task models.Task) ([]models.User, error) {
+	var users []models.User
+	for _, handle := range parseMentions(content) {
+		var matches []models.User
+		query := config.DB.Limit(2)
+		if strings.Contains(handle, "@") {
+			query = query.Where("lower(email) = ?", handle)
+		} else {
+			query = query.Where("lower(split_part(email, '@', 1)) = ?", handle)
+		}
+		if task.ProjectID != nil {
+			query = query.Where("id IN (?)", config.DB.Model(&models.ProjectMember{}).Select("user_id").Where("project_id = ?", *task.ProjectID))
+		}
+		if err := query.Find(&matches).Error; err != nil {
+			return nil, err
+		}
+
+		if len(matches) == 1 && !slices.ContainsFunc(users, func(u models.User) bool { return u.ID == matches[0].ID }) {
+			users = append(users, matches[0])
+		}
+	}
+	return users, nil
+}
+
+// setCommentMentions replaces the mentions of the comment and returns the users newly mentioned
+func setCommentMentions(db *gorm.DB, comment *models.Comment, users []models.User) ([]string, error) {
+	var previous []string
+	if err := db.Model(&models.CommentMention{}).Where("comment_id = ?", comment.ID).Pluck("user_id", &previous).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where("comment_id = ?", comment.ID).Delete(&models.CommentMention{}).Error; err != nil {
+		return nil, err
+	}
+
+	comment.Mentions = nil
+	var added []string
+	for _, user := range users {
+		comment.Mentions = append(comment.Mentions, models.CommentMention{CommentID: comment.ID, UserID: user.ID, User: user})
+		if !slices.Contains(previous, user.ID) {
+			added = append(added, user.ID)
+		}
+	}
+	if len(comment.Mentions) == 0 {
+		return added, nil
+	}
+
+	return added, db.Omit("User").Create(&comment.Mentions).Error
+}
+
+// notifyMentioned tells users they were mentioned in a comment
+func notifyMentioned(db *gorm.DB, task models.Task, comment models.Comment, actor models.User, userIDs []string) error {
+	return notify(db, userIDs, models.Notification{
+		ActorID:   actor.ID,
+		Type:      models.NotificationMentioned,
+		TaskID:    task.ID,
+		CommentID: &comment.ID,
+		Message:   actor.Email + " mentioned you on " + taskName(task),
+	})
+}
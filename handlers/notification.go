@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"slices"
+	"task-management-api/config"
+	"task-management-api/models"
+	"task-management-api/utils"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// GetNotifications lists the user's notifications, newest first. unread=true leaves out read ones.
+func GetNotifications(c *fiber.Ctx) error {
+	user := GetUserByID(c)
+
+	query := config.DB.Model(&models.Notification{}).Preload("Actor").Preload("Task").Where("user_id = ?", user.ID)
+	if c.QueryBool("unread", false) {
+		query = query.Where("read_at IS NULL")
+	}
+	if !isCursorMode(c) {
+		query = query.Order("created_at DESC, id DESC")
+	}
+
+	return paginate(c, query, "notifications.created_at", "notifications.id", notificationCursor, formatNotification)
+}
+
+func GetUnreadNotificationCount(c *fiber.Ctx) error {
+	user := GetUserByID(c)
+
+	var count int64
+	if err := config.DB.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", user.ID).Count(&count).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to count notifications"})
+	}
+
+	return c.JSON(fiber.Map{"unread": count})
+}
+
+func MarkNotificationRead(c *fiber.Ctx) error {
+	user := GetUserByID(c)
+
+	var notification models.Notification
+	if err := config.DB.Preload("Actor").Preload("Task").First(&notification, "id = ? AND user_id = ?", c.Params("id"), user.ID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Notification not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve notification"})
+	}
+
+	if notification.ReadAt == nil {
+		now := time.Now()
+		if err := config.DB.Model(&notification).Update("read_at", now).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update notification"})
+		}
+		notification.ReadAt = &now
+	}
+
+	return c.JSON(models.FormatNotificationResponse(notification))
+}
+
+func MarkAllNotificationsRead(c *fiber.Ctx) error {
+	user := GetUserByID(c)
+
+	res := config.DB.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", user.ID).Update("read_at", time.Now())
+	if res.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update notifications"})
+	}
+
+	return c.JSON(fiber.Map{"updated": res.RowsAffected})
+}
+
+// notify sends the notification to each recipient once, never to the actor themself
+func notify(db *gorm.DB, recipients []string, notification models.Notification) error {
+	var notifications []models.Notification
+	var seen []string
+	for _, userID := range recipients {
+		if userID == "" || userID == notification.ActorID || slices.Contains(seen, userID) {
+			continue
+		}
+		seen = append(seen, userID)
+
+		n := notification
+		n.UserID = userID
+		notifications = append(notifications, n)
+	}
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	return db.Create(&notifications).Error
+}
+
+// notifyAssigned tells users they were assigned to the task
+func notifyAssigned(db *gorm.DB, task models.Task, actor models.User, userIDs []string) error {
+	return notify(db, userIDs, models.Notification{
+		ActorID: actor.ID,
+		Type:    models.NotificationAssigned,
+		TaskID:  task.ID,
+		Message: actor.Email + " assigned you to " + taskName(task),
+	})
+}
+
+// notifyCommented tells the task's watchers about a new comment,
+// except those mentioned in it who already got a mention notification
+func notifyCommented(db *gorm.DB, task models.Task, comment models.Comment, actor models.User) error {
+	var mentioned []string
+	for _, mention := range comment.Mentions {
+		mentioned = append(mentioned, mention.UserID)
+	}
+
+	var watchers []string
+	query := db.Model(&models.TaskWatcher{}).Where("task_id = ?", task.ID)
+	if len(mentioned) > 0 {
+		query = query.Where("user_id NOT IN ?", mentioned)
+	}
+	if err := query.Pluck("user_id", &watchers).Error; err != nil {
+		return err
+	}
+
+	return notify(db, watchers, models.Notification{
+		ActorID:   actor.ID,
+		Type:      models.NotificationCommented,
+		TaskID:    task.ID,
+		CommentID: &comment.ID,
+		Message:   actor.Email + " commented on " + taskName(task),
+	})
+}
+
+// sendNotifications is the notifications subscriber of TaskAssigned, CommentAdded and CommentEdited.
+// Events of tasks deleted in the meantime are dropped.
+func sendNotifications(tx *gorm.DB, event models.OutboxEvent) error {
+	var task models.Task
+	if err := tx.First(&task, "id = ?", event.AggregateID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return err
+	}
+	var actor models.User
+	if err := tx.First(&actor, "id = ?", event.ActorID).Error; err != nil {
+		return err
+	}
+
+	if event.Type == models.TaskAssigned {
+		var payload models.TaskAssignedEvent
+		if err := event.Decode(&payload); err != nil {
+			return err
+		}
+		return notifyAssigned(tx, task, actor, payload.UserIDs)
+	}
+
+	var payload models.CommentEvent
+	if err := event.Decode(&payload); err != nil {
+		return err
+	}
+	var comment models.Comment
+	if err := tx.Preload("Mentions").First(&comment, "id = ?", payload.CommentID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return err
+	}
+
+	if err := notifyMentioned(tx, task, comment, actor, payload.Mentioned); err != nil {
+		return err
+	}
+	if event.Type == models.CommentAdded {
+		return notifyCommented(tx, task, comment, actor)
+	}
+	return nil
+}
+
+// taskName refers to a task by its key and title, or its title alone
+func taskName(task models.Task) string {
+	if task.Key != nil {
+		return *task.Key + " " + task.Title
+	}
+	return task.Title
+}
+
+func notificationCursor(notification models.Notification) utils.Cursor {
+	return utils.Cursor{Time: notification.CreatedAt, ID: notification.ID}
+}
+
+func formatNotification(notification models.Notification) interface{} {
+	return models.FormatNotificationResponse(notification)
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"task-management-api/models"
+	"task-management-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+var errInvalidFilter = errors.New("Invalid filter")
+
+// filterError turns the data exceptions a filter value can still cause in the database, e.g. a date
+// out of range or a custom field value that does not cast, into errInvalidFilter. Fields and operators
+// are checked by the filter parser, so other errors are the server's.
+func filterError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && strings.HasPrefix(pgErr.Code, "22") {
+		return errInvalidFilter
+	}
+	return err
+}
+
+// parsePagination reads page and pageSize, defaulting to page 1 of 10
+func parsePagination(c *fiber.Ctx) (int, int, error) {
+	page, err := utils.StrToInt(c.Query("page", "1"))
+	if err != nil || page < 1 {
+		return 0, 0, errors.New("page must be a positive integer")
+	}
+	pageSize, err := utils.StrToInt(c.Query("pageSize", "10"))
+	if err != nil || pageSize < 1 || pageSize > 100 {
+		return 0, 0, errors.New("pageSize must be an integer between 1 and 100")
+	}
+	return page, pageSize, nil
+}
+
+// return true if the request opts into cursor pagination by sending cursor (empty for the first page)
+func isCursorMode(c *fiber.Ctx) bool {
+	return c.Context().QueryArgs().Has("cursor")
+}
+
+// paginateByPage fetches the requested page of rows along with the total count
+func paginateByPage[T any](query *gorm.DB, page int, pageSize int, format func(T) interface{}) (models.Pagination, error) {
+	result := models.Pagination{Page: page, PageSize: pageSize}
+
+	var count int64
+	if err := query.Session(&gorm.Session{}).Count(&count).Error; err != nil {
+		return result, filterError(err)
+	}
+
+	var rows []T
+	if err := query.Offset((page - 1) * pageSize).Limit(pageSize).Find(&rows).Error; err != nil {
+		return result, filterError(err)
+	}
+
+	for _, row := range rows {
+		result.Data = append(result.Data, format(row))
+	}
+	result.Total = int(count)
+	result.TotalPages = totalPages(count, pageSize)
+
+	return result, nil
+}
+
+// paginateByCursor fetches one page of rows ordered newest first by (timeColumn, idColumn)
+// and returns it formatted, with the cursors of the neighbouring pages.
+// An undecodable token returns utils.ErrInvalidCursor.
+func paginateByCursor[T any](query *gorm.DB, token string, pageSize int, timeColumn string, idColumn string, cursorOf func(T) utils.Cursor, format func(T) interface{}) (models.CursorPagination, error) {
+	result := models.CursorPagination{PageSize: pageSize}
+
+	var cursor utils.Cursor
+	if token != "" {
+		var err error
+		if cursor, err = utils.DecodeCursor(token); err != nil {
+			return result, err
+		}
+	}
+
+	// Fetch one extra row to know whether there is another page in that direction
+	query = query.Limit(pageSize + 1)
+	if cursor.Before {
+		query = query.Where("("+timeColumn+", "+idColumn+") > (?, ?)", cursor.Time, cursor.ID).Order(timeColumn + " ASC, " + idColumn + " ASC")
+	} else {
+		if token != "" {
+			query = query.Where("("+timeColumn+", "+idColumn+") < (?, ?)", cursor.Time, cursor.ID)
+		}
+		query = query.Order(timeColumn + " DESC, " + idColumn + " DESC")
+	}
+
+	var rows []T
+	if err := query.Find(&rows).Error; err != nil {
+		return result, filterError(err)
+	}
+
+	hasMore := len(rows) > pageSize
+	if hasMore {
+		rows = rows[:pageSize]
+	}
+	if cursor.Before {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+
+	if len(rows) > 0 {
+		first := cursorOf(rows[0])
+		last := cursorOf(rows[len(rows)-1])
+		first.Before = true
+
+		// Going back we came from a later page, going forward from an earlier one
+		if hasMore || cursor.Before {
+			result.NextCursor = utils.EncodeCursor(last)
+		}
+		if (hasMore && cursor.Before) || (!cursor.Before && token != "") {
+			result.PrevCursor = utils.EncodeCursor(first)
+		}
+	}
+
+	for _, row := range rows {
+		result.Data = append(result.Data, format(row))
+	}
+
+	return result, nil
+}
+
+// paginate responds with a page of rows, in cursor mode when the request asks for it
+func paginate[T any](c *fiber.Ctx, query *gorm.DB, timeColumn string, idColumn string, cursorOf func(T) utils.Cursor, format func(T) interface{}) error {
+	page, pageSize, err := parsePagination(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// Keyset pagination skips the count and stays stable as rows change
+	if isCursorMode(c) {
+		result, err := paginateByCursor(query, c.Query("cursor", ""), pageSize, timeColumn, idColumn, cursorOf, format)
+		if errors.Is(err, utils.ErrInvalidCursor) || errors.Is(err, errInvalidFilter) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve records"})
+		}
+		return c.JSON(models.TransformCursorPagination(&result))
+	}
+
+	result, err := paginateByPage(query, page, pageSize, format)
+	if errors.Is(err, errInvalidFilter) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve records"})
+	}
+	return c.JSON(models.TransformPagination(&result))
+}
+
+func totalPages(count int64, pageSize int) int {
+	if count%int64(pageSize) == 0 {
+		return int(count) / pageSize
+	}
+	return int(count)/pageSize + 1
+}
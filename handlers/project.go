@@ -0,0 +1,305 @@
+package handlers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"task-management-api/config"
+	"task-management-api/models"
+	"task-management-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ProjectRequest struct {
+	Name        string `json:"name"`
+	Key         string `json:"key"`
+	Description string `json:"description"`
+}
+
+type ProjectMemberRequest struct {
+	UserID string `json:"user_id"`
+}
+
+var projectKeyPattern = regexp.MustCompile(`^[A-Z][A-Z0-9]{1,9}$`)
+
+// GetAllProjects lists the projects the user is a member of, or every project for admins
+func GetAllProjects(c *fiber.Ctx) error {
+	user := GetUserByID(c)
+
+	query := config.DB.Preload("Owner").Order("key")
+	if !utils.RoleHasPermission(GetRole(c), utils.ProjectManageAny) {
+		query = query.Where("id IN (?)", config.DB.Model(&models.ProjectMember{}).Select("project_id").Where("user_id = ?", user.ID))
+	}
+
+	var projects []models.Project
+	if err := query.Find(&projects).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve projects"})
+	}
+
+	response := []models.ProjectResponse{}
+	for _, project := range projects {
+		response = append(response, models.FormatProjectResponse(project, nil))
+	}
+
+	return c.JSON(response)
+}
+
+func CreateProject(c *fiber.Ctx) error {
+	user := GetUserByID(c)
+
+	var req ProjectRequest
+	if err := c.BodyParser(&req); err != nil || req.Name == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	req.Key = strings.ToUpper(req.Key)
+	if !projectKeyPattern.MatchString(req.Key) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Key must be 2-10 uppercase letters or digits, starting with a letter"})
+	}
+
+	project := models.Project{
+		Name:        req.Name,
+		Key:         req.Key,
+		Description: req.Description,
+		OwnerID:     user.ID,
+	}
+
+	// Create the project and add the owner as its first member
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&project).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.ProjectMember{ProjectID: project.ID, UserID: user.ID}).Error
+	})
+	if err != nil {
+		if err == gorm.ErrDuplicatedKey || strings.Contains(err.Error(), "duplicate key") {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Project key already exists"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create project"})
+	}
+
+	project.Owner = user
+
+	return c.Status(fiber.StatusCreated).JSON(models.FormatProjectResponse(project, nil))
+}
+
+func GetProjectByKey(c *fiber.Ctx) error {
+	project, ok := getProjectForMember(c)
+	if !ok {
+		return nil
+	}
+
+	var members []models.ProjectMember
+	if err := config.DB.Preload("User").Where("project_id = ?", project.ID).Find(&members).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve project members"})
+	}
+
+	return c.JSON(models.FormatProjectResponse(project, members))
+}
+
+func UpdateProject(c *fiber.Ctx) error {
+	project, ok := getProjectForOwner(c)
+	if !ok {
+		return nil
+	}
+
+	// The key is part of every task key, so only name and description can change
+	var req ProjectRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	payload := models.Project{
+		Name:        req.Name,
+		Description: req.Description,
+	}
+	if err := config.DB.Model(&project).Updates(payload).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update project"})
+	}
+
+	return c.JSON(models.FormatProjectResponse(project, nil))
+}
+
+func DeleteProject(c *fiber.Ctx) error {
+	project, ok := getProjectForOwner(c)
+	if !ok {
+		return nil
+	}
+
+	var count int64
+	if err := config.DB.Model(&models.Task{}).Where("project_id = ?", project.ID).Count(&count).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve project tasks"})
+	}
+	if count > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Project still has tasks"})
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("project_id = ?", project.ID).Delete(&models.ProjectMember{}).Error; err != nil {
+			return err
+		}
+		webhooks := tx.Model(&models.Webhook{}).Select("id").Where("project_id = ?", project.ID)
+		if err := tx.Where("webhook_id IN (?)", webhooks).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("project_id = ?", project.ID).Delete(&models.Webhook{}).Error; err != nil {
+			return err
+		}
+		sprints := tx.Model(&models.Sprint{}).Select("id").Where("project_id = ?", project.ID)
+		if err := tx.Where("sprint_id IN (?)", sprints).Delete(&models.SprintTask{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("project_id = ?", project.ID).Delete(&models.Sprint{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&project).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete project"})
+	}
+
+	return c.Status(fiber.StatusOK).SendString("Project deleted")
+}
+
+func AddProjectMember(c *fiber.Ctx) error {
+	project, ok := getProjectForOwner(c)
+	if !ok {
+		return nil
+	}
+
+	var req ProjectMemberRequest
+	if err := c.BodyParser(&req); err != nil || req.UserID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	if !validateAssignee(req.UserID, nil) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "User must be a valid user ID"})
+	}
+
+	member := models.ProjectMember{ProjectID: project.ID, UserID: req.UserID}
+	if err := config.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&member).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to add member"})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(member)
+}
+
+func RemoveProjectMember(c *fiber.Ctx) error {
+	project, ok := getProjectForOwner(c)
+	if !ok {
+		return nil
+	}
+
+	userID := c.Params("userId")
+	if userID == project.OwnerID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "The project owner cannot be removed"})
+	}
+
+	if err := config.DB.Where("project_id = ? AND user_id = ?", project.ID, userID).Delete(&models.ProjectMember{}).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to remove member"})
+	}
+
+	return c.Status(fiber.StatusOK).SendString("Member removed")
+}
+
+// GetProjectTasks lists the tasks of a project with the same filters as GetAllTasks
+func GetProjectTasks(c *fiber.Ctx) error {
+	project, ok := getProjectForMember(c)
+	if !ok {
+		return nil
+	}
+
+	return listTasks(c, config.DB.Model(&models.Task{}).Where("tasks.project_id = ?", project.ID), &project.ID)
+}
+
+func CreateProjectTask(c *fiber.Ctx) error {
+	project, ok := getProjectForMember(c)
+	if !ok {
+		return nil
+	}
+
+	var task models.Task
+	if err := c.BodyParser(&task); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	task.ProjectID = &project.ID
+
+	return createTask(c, task)
+}
+
+// getProjectForMember loads the project from the :key param and checks that the user can see it.
+// When ok is false the error response has already been written.
+func getProjectForMember(c *fiber.Ctx) (project models.Project, ok bool) {
+	project, ok = getProjectByKey(c)
+	if !ok {
+		return project, false
+	}
+
+	user := GetUserByID(c)
+	if !isProjectMember(c, project.ID, user.ID) {
+		c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not a member of this project"})
+		return project, false
+	}
+
+	return project, true
+}
+
+// getProjectForOwner loads the project from the :key param and checks that the user can manage it.
+// When ok is false the error response has already been written.
+func getProjectForOwner(c *fiber.Ctx) (project models.Project, ok bool) {
+	project, ok = getProjectByKey(c)
+	if !ok {
+		return project, false
+	}
+
+	user := GetUserByID(c)
+	if !utils.HasPermission(GetRole(c), utils.ProjectManageAny, project.OwnerID, user.ID) {
+		c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not authorized to manage this project"})
+		return project, false
+	}
+
+	return project, true
+}
+
+func getProjectByKey(c *fiber.Ctx) (project models.Project, ok bool) {
+	if err := config.DB.Preload("Owner").First(&project, "key = ?", strings.ToUpper(c.Params("key"))).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Project not found"})
+			return project, false
+		}
+		c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve project"})
+		return project, false
+	}
+	return project, true
+}
+
+// return true if the user belongs to the project or can manage any project
+func isProjectMember(c *fiber.Ctx, projectID string, userID string) bool {
+	if utils.RoleHasPermission(GetRole(c), utils.ProjectManageAny) {
+		return true
+	}
+
+	var count int64
+	config.DB.Model(&models.ProjectMember{}).Where("project_id = ? AND user_id = ?", projectID, userID).Count(&count)
+	return count > 0
+}
+
+// assignTaskKey issues the next task number of the project, e.g. WEB-124.
+// The project row is locked so concurrent creations get distinct numbers.
+func assignTaskKey(tx *gorm.DB, task *models.Task) error {
+	var project models.Project
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&project, "id = ?", *task.ProjectID).Error; err != nil {
+		return err
+	}
+
+	project.TaskCounter++
+	if err := tx.Model(&project).Update("task_counter", project.TaskCounter).Error; err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("%s-%d", project.Key, project.TaskCounter)
+	task.Key = &key
+	return nil
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"net/url"
+	"strings"
+	"task-management-api/config"
+	"task-management-api/models"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReactionRequest struct {
+	Emoji string `json:"emoji"`
+}
+
+// GetCommentReactions lists the reactions to a comment, counted per emoji
+func GetCommentReactions(c *fiber.Ctx) error {
+	comment, ok := getReactionComment(c)
+	if !ok {
+		return nil
+	}
+
+	reactions, err := getCommentReactions(comment.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve reactions"})
+	}
+
+	return c.JSON(reactions)
+}
+
+// AddCommentReaction reacts to a comment with an emoji, reacting twice with the same one changes nothing
+func AddCommentReaction(c *fiber.Ctx) error {
+	user := GetUserByID(c)
+	comment, ok := getReactionComment(c)
+	if !ok {
+		return nil
+	}
+
+	var req ReactionRequest
+	if err := c.BodyParser(&req); err != nil || !validEmoji(req.Emoji) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Emoji must be a single emoji"})
+	}
+
+	reaction := models.CommentReaction{CommentID: comment.ID, UserID: user.ID, Emoji: req.Emoji}
+	if err := config.DB.Clauses(clause.OnConflict{DoNothing: true}).Omit("User").Create(&reaction).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to add reaction"})
+	}
+
+	reactions, err := getCommentReactions(comment.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve reactions"})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(reactions)
+}
+
+// RemoveCommentReaction takes back the user's reaction with the emoji in the path (URL encoded)
+func RemoveCommentReaction(c *fiber.Ctx) error {
+	user := GetUserByID(c)
+	comment, ok := getReactionComment(c)
+	if !ok {
+		return nil
+	}
+
+	emoji, err := url.PathUnescape(c.Params("emoji"))
+	if err != nil || !validEmoji(emoji) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Emoji must be a single emoji"})
+	}
+
+	res := config.DB.Where("comment_id = ? AND user_id = ? AND emoji = ?", comment.ID, user.ID, emoji).Delete(&models.CommentReaction{})
+	if res.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to remove reaction"})
+	}
+	if res.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Reaction not found"})
+	}
+
+	reactions, err := getCommentReactions(comment.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve reactions"})
+	}
+
+	return c.JSON(reactions)
+}
+
+// getReactionComment loads the comment from the path, writing the error response when it cannot
+func getReactionComment(c *fiber.Ctx) (models.Comment, bool) {
+	var comment models.Comment
+	if err := config.DB.First(&comment, "id = ?", c.Params("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Comment not found"})
+			return comment, false
+		}
+		c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve comment"})
+		return comment, false
+	}
+
+	var task models.Task
+	if err := config.DB.First(&task, "id = ?", comment.TaskID).Error; err != nil || !canViewTask(c, task) {
+		c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Comment not found"})
+		return comment, false
+	}
+	return comment, true
+}
+
+func getCommentReactions(commentID string) ([]models.ReactionResponse, error) {
+	var reactions []models.CommentReaction
+	if err := config.DB.Preload("User").Where("comment_id = ?", commentID).Find(&reactions).Error; err != nil {
+		return nil, err
+	}
+	return models.FormatReactionsResponse(reactions), nil
+}
+
+// validEmoji accepts emoji, including skin tones, flags, keycaps and sequences joined with
+// zero width joiners, and rejects text
+func validEmoji(emoji string) bool {
+	if emoji == "" || len(emoji) > 32 || !utf8.ValidString(emoji) {
+		return false
+	}
+
+	symbols := 0
+	for i, r := range emoji {
+		switch {
+		case unicode.Is(unicode.So, r):
+			symbols++
+		case r == '\u200d', r == '\ufe0f', r == '\ufe0e', unicode.Is(unicode.Sk, r): // joiner, variation selectors, skin tones
+		case r >= 0xe0020 && r <= 0xe007f: // tags of subdivision flags
+		case r == '\u20e3': // keycap, like 1️⃣
+			symbols++
+		case i == 0 && strings.ContainsRune("#*0123456789", r) && strings.HasSuffix(emoji, "\u20e3"):
+		default:
+			return false
+		}
+	}
+	return symbols > 0
+}
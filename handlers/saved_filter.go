@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"task-management-api/config"
+	"task-management-api/models"
+	"task-management-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type SavedFilterRequest struct {
+	Name      string            `json:"name"`
+	ProjectID *string           `json:"project_id"`
+	Criteria  models.TaskFilter `json:"criteria"`
+}
+
+// GetAllSavedFilters lists the user's own filters and those shared with their projects
+func GetAllSavedFilters(c *fiber.Ctx) error {
+	user := GetUserByID(c)
+
+	memberOf := config.DB.Model(&models.ProjectMember{}).Select("project_id").Where("user_id = ?", user.ID)
+
+	var filters []models.SavedFilter
+	if err := config.DB.Preload("Owner").Where("owner_id = ? OR project_id IN (?)", user.ID, memberOf).Order("name").Find(&filters).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve filters"})
+	}
+
+	response := []models.SavedFilterResponse{}
+	for _, filter := range filters {
+		f, err := models.FormatSavedFilterResponse(filter)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to read filter"})
+		}
+		response = append(response, f)
+	}
+
+	return c.JSON(response)
+}
+
+func GetSavedFilterById(c *fiber.Ctx) error {
+	filter, status, err := getVisibleSavedFilter(c, c.Params("id"))
+	if err != nil {
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	response, err := models.FormatSavedFilterResponse(filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to read filter"})
+	}
+
+	return c.JSON(response)
+}
+
+func CreateSavedFilter(c *fiber.Ctx) error {
+	user := GetUserByID(c)
+
+	var req SavedFilterRequest
+	if err := c.BodyParser(&req); err != nil || req.Name == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	filter := models.SavedFilter{
+		Name:      req.Name,
+		OwnerID:   user.ID,
+		ProjectID: req.ProjectID,
+	}
+	if status, err := setSavedFilterFields(c, &filter, req); err != nil {
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := config.DB.Create(&filter).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create filter"})
+	}
+
+	filter.Owner = user
+	response, _ := models.FormatSavedFilterResponse(filter)
+
+	return c.Status(fiber.StatusCreated).JSON(response)
+}
+
+func UpdateSavedFilter(c *fiber.Ctx) error {
+	user := GetUserByID(c)
+
+	var filter models.SavedFilter
+	if err := config.DB.First(&filter, "id = ?", c.Params("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Filter not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve filter"})
+	}
+
+	// Shared filters stay editable by their owner only
+	if filter.OwnerID != user.ID {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not authorized to update this filter"})
+	}
+
+	var req SavedFilterRequest
+	if err := c.BodyParser(&req); err != nil || req.Name == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	filter.Name = req.Name
+	filter.ProjectID = req.ProjectID
+	if status, err := setSavedFilterFields(c, &filter, req); err != nil {
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := config.DB.Model(&filter).Select("name", "project_id", "criteria", "updated_at").Updates(&filter).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update filter"})
+	}
+
+	filter.Owner = user
+	response, _ := models.FormatSavedFilterResponse(filter)
+
+	return c.JSON(response)
+}
+
+func DeleteSavedFilter(c *fiber.Ctx) error {
+	user := GetUserByID(c)
+
+	var filter models.SavedFilter
+	if err := config.DB.First(&filter, "id = ?", c.Params("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Filter not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve filter"})
+	}
+
+	if !utils.HasPermission(GetRole(c), utils.SavedFilterManageAny, filter.OwnerID, user.ID) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not authorized to delete this filter"})
+	}
+
+	if err := config.DB.Delete(&filter).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete filter"})
+	}
+
+	return c.Status(fiber.StatusOK).SendString("Filter deleted")
+}
+
+// setSavedFilterFields validates the sharing project and the criteria before storing them
+func setSavedFilterFields(c *fiber.Ctx, filter *models.SavedFilter, req SavedFilterRequest) (int, error) {
+	if req.ProjectID != nil && *req.ProjectID == "" {
+		filter.ProjectID = nil
+	}
+	if filter.ProjectID != nil && !isProjectMember(c, *filter.ProjectID, filter.OwnerID) {
+		return fiber.StatusForbidden, errors.New("Filters can only be shared with your projects")
+	}
+
+	if err := validateTaskFilter(req.Criteria, filter.ProjectID); err != nil {
+		return fiber.StatusBadRequest, err
+	}
+
+	criteria, err := json.Marshal(req.Criteria)
+	if err != nil {
+		return fiber.StatusInternalServerError, err
+	}
+	filter.Criteria = string(criteria)
+
+	return 0, nil
+}
+
+// getVisibleSavedFilter loads a saved filter owned by the user or shared with one of their projects.
+// It returns the HTTP status to respond with when the filter cannot be used.
+func getVisibleSavedFilter(c *fiber.Ctx, filterID string) (models.SavedFilter, int, error) {
+	var filter models.SavedFilter
+
+	userID := currentUserID(c)
+	if userID == "" {
+		return filter, fiber.StatusUnauthorized, errors.New("Saved filters require authentication")
+	}
+
+	if err := config.DB.Preload("Owner").First(&filter, "id = ?", filterID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return filter, fiber.StatusNotFound, errors.New("Filter not found")
+		}
+		return filter, fiber.StatusInternalServerError, errors.New("Failed to retrieve filter")
+	}
+
+	if filter.OwnerID != userID && (filter.ProjectID == nil || !isProjectMember(c, *filter.ProjectID, userID)) {
+		return filter, fiber.StatusNotFound, errors.New("Filter not found")
+	}
+
+	return filter, 0, nil
+}
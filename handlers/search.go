@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"strings"
+	"task-management-api/config"
+	"task-management-api/models"
+	"task-management-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const headlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2"
+
+// escapeHTML escapes the text given by expr in SQL, so the only markup in a highlight is the <mark> added by ts_headline
+func escapeHTML(expr string) string {
+	return "replace(replace(replace(replace(" + expr + ", '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '\"', '&quot;')"
+}
+
+var taskSearchQuery = `
+	SELECT 'task' AS type, t.id, t.id AS task_id, t.key AS task_key, t.title,
+		ts_headline('english', ` + escapeHTML("coalesce(t.title, '') || ' ' || coalesce(t.description, '')") + `, q, '` + headlineOptions + `') AS highlight,
+		ts_rank(t.search_vector, q) AS rank, t.updated_at
+	FROM tasks t, websearch_to_tsquery('english', @q) q
+	WHERE t.search_vector @@ q`
+
+var commentSearchQuery = `
+	SELECT 'comment' AS type, c.id, c.task_id, t.key AS task_key, t.title,
+		ts_headline('english', ` + escapeHTML("c.content") + `, q, '` + headlineOptions + `') AS highlight,
+		ts_rank(c.search_vector, q) AS rank, c.updated_at
+	FROM comments c JOIN tasks t ON t.id = c.task_id, websearch_to_tsquery('english', @q) q
+	WHERE c.search_vector @@ q`
+
+// Search runs a ranked full-text search over task titles, descriptions and comments,
+// among the tasks without a project and those of the user's projects
+func Search(c *fiber.Ctx) error {
+	user := GetUserByID(c)
+
+	q := strings.TrimSpace(c.Query("q", ""))
+	if q == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Query parameter q is required"})
+	}
+
+	page, pageSize, err := parsePagination(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	searchType := c.Query("type", "")
+	if searchType != "" && searchType != models.SearchTypeTask && searchType != models.SearchTypeComment {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "type must be one of: task, comment"})
+	}
+
+	projectFilter := ""
+	args := map[string]interface{}{"q": q}
+	if !utils.RoleHasPermission(GetRole(c), utils.ProjectManageAny) {
+		projectFilter = " AND (t.project_id IS NULL OR t.project_id IN (SELECT project_id FROM project_members WHERE user_id = @user))"
+		args["user"] = user.ID
+	}
+
+	// Optionally limit hits to one project
+	if projectKey := c.Query("project", ""); projectKey != "" {
+		projectFilter += " AND t.project_id = (SELECT id FROM projects WHERE key = @project)"
+		args["project"] = strings.ToUpper(projectKey)
+	}
+
+	var parts []string
+	if searchType != models.SearchTypeComment {
+		parts = append(parts, taskSearchQuery+projectFilter)
+	}
+	if searchType != models.SearchTypeTask {
+		parts = append(parts, commentSearchQuery+projectFilter)
+	}
+	hits := strings.Join(parts, " UNION ALL ")
+
+	var count int64
+	if err := config.DB.Raw("SELECT count(*) FROM ("+hits+") hits", args).Scan(&count).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to search"})
+	}
+
+	args["limit"] = pageSize
+	args["offset"] = (page - 1) * pageSize
+
+	var results []models.SearchResult
+	if err := config.DB.Raw(hits+" ORDER BY rank DESC, updated_at DESC LIMIT @limit OFFSET @offset", args).Scan(&results).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to search"})
+	}
+
+	var data []interface{}
+	for _, result := range results {
+		data = append(data, result)
+	}
+
+	response := models.TransformPagination(&models.Pagination{
+		Page:       page,
+		PageSize:   pageSize,
+		Total:      int(count),
+		TotalPages: totalPages(count, pageSize),
+		Data:       data,
+	})
+
+	return c.JSON(response)
+}
@@ -0,0 +1,598 @@
+package handlers
+
+import (
+	"slices"
+	"strings"
+	"task-management-api/config"
+	"task-management-api/events"
+	"task-management-api/models"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SprintRequest struct {
+	Name      string     `json:"name"`
+	Goal      string     `json:"goal"`
+	StartDate *time.Time `json:"start_date"`
+	EndDate   *time.Time `json:"end_date"`
+}
+
+type SprintTasksRequest struct {
+	TaskIDs []string `json:"task_ids"`
+}
+
+type CompleteSprintRequest struct {
+	CarryOverTo string `json:"carry_over_to"` // a planned sprint ID or "backlog", the next planned sprint when empty
+}
+
+// sprints last two weeks unless an end date is given
+const sprintLength = 14 * 24 * time.Hour
+
+// GetProjectSprints lists the sprints of a project in planning order, state= filters them
+func GetProjectSprints(c *fiber.Ctx) error {
+	project, ok := getProjectForMember(c)
+	if !ok {
+		return nil
+	}
+
+	query := config.DB.Where("project_id = ?", project.ID).Order("start_date ASC NULLS LAST, created_at")
+	if state := strings.ToUpper(c.Query("state", "")); state != "" {
+		query = query.Where("state = ?", state)
+	}
+
+	var sprints []models.Sprint
+	if err := query.Find(&sprints).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve sprints"})
+	}
+
+	response := []models.SprintResponse{}
+	for _, sprint := range sprints {
+		response = append(response, models.FormatSprintResponse(sprint))
+	}
+
+	return c.JSON(response)
+}
+
+func CreateSprint(c *fiber.Ctx) error {
+	project, ok := getProjectForMember(c)
+	if !ok {
+		return nil
+	}
+	user := GetUserByID(c)
+
+	var req SprintRequest
+	if err := c.BodyParser(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	if req.StartDate != nil && req.EndDate != nil && req.EndDate.Before(*req.StartDate) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "End date must not be before start date"})
+	}
+
+	sprint := models.Sprint{
+		ProjectID: project.ID,
+		Name:      strings.TrimSpace(req.Name),
+		Goal:      req.Goal,
+		State:     models.SprintPlanned,
+		StartDate: req.StartDate,
+		EndDate:   req.EndDate,
+		CreatedBy: user.ID,
+	}
+	if err := config.DB.Create(&sprint).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create sprint"})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.FormatSprintResponse(sprint))
+}
+
+// GetSprint returns a sprint with the tasks currently in it, in board order
+func GetSprint(c *fiber.Ctx) error {
+	sprint, ok := getSprint(c)
+	if !ok {
+		return nil
+	}
+
+	var tasks []models.Task
+	if err := config.DB.Preload("Labels").Preload("CoAssignees").Where("sprint_id = ?", sprint.ID).Order(rankOrder).Find(&tasks).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve tasks"})
+	}
+
+	response := models.FormatSprintResponse(sprint)
+	response.Tasks = []models.TaskResponse{}
+	for _, task := range tasks {
+		response.Tasks = append(response.Tasks, renderTask(c, models.FormatTaskResponse(task)))
+	}
+
+	return c.JSON(response)
+}
+
+// UpdateSprint changes the name, goal or dates of a sprint that is not completed
+func UpdateSprint(c *fiber.Ctx) error {
+	sprint, ok := getSprint(c)
+	if !ok {
+		return nil
+	}
+	if sprint.State == models.SprintCompleted {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Sprint is completed"})
+	}
+
+	var req SprintRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	payload := models.Sprint{
+		Name:      strings.TrimSpace(req.Name),
+		Goal:      req.Goal,
+		StartDate: req.StartDate,
+		EndDate:   req.EndDate,
+	}
+	startDate, endDate := sprint.StartDate, sprint.EndDate
+	if req.StartDate != nil {
+		startDate = req.StartDate
+	}
+	if req.EndDate != nil {
+		endDate = req.EndDate
+	}
+	if startDate != nil && endDate != nil && endDate.Before(*startDate) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "End date must not be before start date"})
+	}
+
+	if err := config.DB.Model(&sprint).Updates(payload).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update sprint"})
+	}
+
+	return c.JSON(models.FormatSprintResponse(sprint))
+}
+
+// DeleteSprint deletes a planned sprint, its tasks go back to the backlog
+func DeleteSprint(c *fiber.Ctx) error {
+	sprint, ok := getSprint(c)
+	if !ok {
+		return nil
+	}
+	if sprint.State != models.SprintPlanned {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Only planned sprints can be deleted"})
+	}
+	user := GetUserByID(c)
+
+	var taskIDs []string
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Task{}).Where("sprint_id = ?", sprint.ID).Pluck("id", &taskIDs).Error; err != nil {
+			return err
+		}
+		if err := moveToSprint(tx, taskIDs, &sprint, nil, user.ID); err != nil {
+			return err
+		}
+		if err := tx.Where("sprint_id = ?", sprint.ID).Delete(&models.SprintTask{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&sprint).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete sprint"})
+	}
+	dispatchAll(taskIDs)
+
+	return c.Status(fiber.StatusOK).SendString("Sprint deleted")
+}
+
+// AddSprintTasks moves project tasks into a sprint that is not completed, taking them out of
+// any other sprint. Tasks added to an active sprint count as scope added after the start.
+func AddSprintTasks(c *fiber.Ctx) error {
+	sprint, ok := getSprint(c)
+	if !ok {
+		return nil
+	}
+	if sprint.State == models.SprintCompleted {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Sprint is completed"})
+	}
+	user := GetUserByID(c)
+
+	var req SprintTasksRequest
+	if err := c.BodyParser(&req); err != nil || len(req.TaskIDs) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "task_ids must list at least one task"})
+	}
+
+	var tasks []models.Task
+	for _, id := range req.TaskIDs {
+		var task models.Task
+		if err := config.DB.Where(taskIDCondition(id)).First(&task).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Task " + id + " not found"})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve task"})
+		}
+		if task.ProjectID == nil || *task.ProjectID != sprint.ProjectID {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Task " + id + " does not belong to the sprint's project"})
+		}
+		if (task.SprintID == nil || *task.SprintID != sprint.ID) && !slices.ContainsFunc(tasks, func(t models.Task) bool { return t.ID == task.ID }) {
+			tasks = append(tasks, task)
+		}
+	}
+
+	var taskIDs []string
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		// tasks leaving an active sprint are recorded as removed from it, the done tasks
+		// of a completed sprint keep their outcome
+		for _, task := range tasks {
+			taskIDs = append(taskIDs, task.ID)
+			if task.SprintID == nil {
+				continue
+			}
+			var from models.Sprint
+			if err := tx.First(&from, "id = ?", *task.SprintID).Error; err != nil {
+				return err
+			}
+			if from.State != models.SprintCompleted {
+				if err := removeFromSprint(tx, from, task.ID); err != nil {
+					return err
+				}
+			}
+			if err := moveToSprint(tx, []string{task.ID}, &from, &sprint, user.ID); err != nil {
+				return err
+			}
+		}
+
+		var fromBacklog []string
+		for _, task := range tasks {
+			if task.SprintID == nil {
+				fromBacklog = append(fromBacklog, task.ID)
+			}
+		}
+		if err := moveToSprint(tx, fromBacklog, nil, &sprint, user.ID); err != nil {
+			return err
+		}
+
+		for _, task := range tasks {
+			if err := addToSprint(tx, sprint, task.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to add tasks to sprint"})
+	}
+	dispatchAll(taskIDs)
+
+	return c.JSON(fiber.Map{"added": len(tasks)})
+}
+
+// RemoveSprintTask puts a task of a sprint that is not completed back in the backlog
+func RemoveSprintTask(c *fiber.Ctx) error {
+	sprint, ok := getSprint(c)
+	if !ok {
+		return nil
+	}
+	if sprint.State == models.SprintCompleted {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Sprint is completed"})
+	}
+	user := GetUserByID(c)
+
+	var task models.Task
+	if err := config.DB.Where(taskIDCondition(c.Params("taskId"))).Where("sprint_id = ?", sprint.ID).First(&task).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Task is not in this sprint"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve task"})
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := removeFromSprint(tx, sprint, task.ID); err != nil {
+			return err
+		}
+		return moveToSprint(tx, []string{task.ID}, &sprint, nil, user.ID)
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to remove task from sprint"})
+	}
+	events.Dispatch(task.ID)
+
+	return c.Status(fiber.StatusOK).SendString("Task removed from sprint")
+}
+
+// StartSprint activates a planned sprint, a project runs one sprint at a time
+func StartSprint(c *fiber.Ctx) error {
+	sprint, ok := getSprint(c)
+	if !ok {
+		return nil
+	}
+	if sprint.State != models.SprintPlanned {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Only planned sprints can be started"})
+	}
+
+	now := time.Now()
+	if sprint.StartDate == nil || sprint.StartDate.After(now) {
+		sprint.StartDate = &now
+	}
+	if sprint.EndDate == nil || sprint.EndDate.Before(*sprint.StartDate) {
+		end := sprint.StartDate.Add(sprintLength)
+		sprint.EndDate = &end
+	}
+
+	conflict := ""
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		// the project row is locked so two sprints cannot start at once
+		var project models.Project
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&project, "id = ?", sprint.ProjectID).Error; err != nil {
+			return err
+		}
+		var count int64
+		if err := tx.Model(&models.Sprint{}).Where("project_id = ? AND state = ?", sprint.ProjectID, models.SprintActive).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			conflict = "The project already has an active sprint"
+			return nil
+		}
+
+		result := tx.Model(&sprint).Where("state = ?", models.SprintPlanned).
+			Updates(models.Sprint{State: models.SprintActive, StartDate: sprint.StartDate, EndDate: sprint.EndDate})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			conflict = "Only planned sprints can be started"
+		}
+		return nil
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to start sprint"})
+	}
+	if conflict != "" {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": conflict})
+	}
+
+	return c.JSON(models.FormatSprintResponse(sprint))
+}
+
+// CompleteSprint closes an active sprint. Its done tasks stay in it, the others are carried over
+// to the sprint given by carry_over_to, by default the next planned sprint, or to the backlog.
+func CompleteSprint(c *fiber.Ctx) error {
+	sprint, ok := getSprint(c)
+	if !ok {
+		return nil
+	}
+	if sprint.State != models.SprintActive {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Only active sprints can be completed"})
+	}
+	user := GetUserByID(c)
+
+	var req CompleteSprintRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+		}
+	}
+
+	var next *models.Sprint
+	switch req.CarryOverTo {
+	case "backlog":
+	case "":
+		var planned []models.Sprint
+		if err := config.DB.Where("project_id = ? AND state = ?", sprint.ProjectID, models.SprintPlanned).
+			Order("start_date ASC NULLS LAST, created_at").Limit(1).Find(&planned).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve sprints"})
+		}
+		if len(planned) > 0 {
+			next = &planned[0]
+		}
+	default:
+		var target models.Sprint
+		if err := config.DB.First(&target, "id = ? AND project_id = ? AND state = ?", req.CarryOverTo, sprint.ProjectID, models.SprintPlanned).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "carry_over_to must be a planned sprint of the project or backlog"})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve sprint"})
+		}
+		next = &target
+	}
+
+	workflow, err := getWorkflow(&sprint.ProjectID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve workflow"})
+	}
+	doneStates := workflow.DoneStates()
+
+	var carried []string
+	completed := true
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		// the state check keeps a sprint from being completed twice
+		now := time.Now()
+		result := tx.Model(&sprint).Where("state = ?", models.SprintActive).Select("state", "completed_at", "carried_over_to").
+			Updates(models.Sprint{State: models.SprintCompleted, CompletedAt: &now, CarriedOverTo: sprintID(next)})
+		if result.Error != nil {
+			return result.Error
+		}
+		if completed = result.RowsAffected > 0; !completed {
+			return nil
+		}
+
+		var tasks []models.Task
+		if err := tx.Where("sprint_id = ?", sprint.ID).Find(&tasks).Error; err != nil {
+			return err
+		}
+
+		for _, task := range tasks {
+			outcome := models.SprintTaskCompleted
+			if !slices.Contains(doneStates, string(task.Status)) {
+				outcome = models.SprintTaskCarriedOver
+				carried = append(carried, task.ID)
+			}
+			if err := tx.Model(&models.SprintTask{}).Where("sprint_id = ? AND task_id = ?", sprint.ID, task.ID).
+				Updates(map[string]interface{}{"outcome": outcome, "status": task.Status, "estimate": task.Estimate}).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := moveToSprint(tx, carried, &sprint, next, user.ID); err != nil {
+			return err
+		}
+		if next != nil {
+			for _, taskID := range carried {
+				if err := addToSprint(tx, *next, taskID); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to complete sprint"})
+	}
+	if !completed {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Only active sprints can be completed"})
+	}
+	dispatchAll(carried)
+
+	return sprintReport(c, sprint.ID)
+}
+
+// GetSprintReport sums up the completed and carried over work of a sprint
+func GetSprintReport(c *fiber.Ctx) error {
+	sprint, ok := getSprint(c)
+	if !ok {
+		return nil
+	}
+
+	return sprintReport(c, sprint.ID)
+}
+
+func sprintReport(c *fiber.Ctx, sprintID string) error {
+	var sprint models.Sprint
+	if err := config.DB.First(&sprint, "id = ?", sprintID).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve sprint"})
+	}
+
+	var records []models.SprintTask
+	if err := config.DB.Preload("Task").Where("sprint_id = ?", sprint.ID).Order("added_at").Find(&records).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve sprint tasks"})
+	}
+
+	// an active sprint is reported as it stands, with the tasks' current status
+	var doneStates []string
+	if sprint.State != models.SprintCompleted {
+		workflow, err := getWorkflow(&sprint.ProjectID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve workflow"})
+		}
+		doneStates = workflow.DoneStates()
+	}
+
+	empty := func() models.SprintReportGroup { return models.SprintReportGroup{Tasks: []models.SprintReportTask{}} }
+	report := models.SprintReport{
+		Sprint:      models.FormatSprintResponse(sprint),
+		Completed:   empty(),
+		CarriedOver: empty(),
+		Removed:     empty(),
+	}
+	for _, record := range records {
+		task := models.SprintReportTask{
+			ID:              record.TaskID,
+			Key:             record.Task.Key,
+			Title:           record.Task.Title,
+			Status:          record.Status,
+			Estimate:        record.Estimate,
+			AddedAfterStart: record.AddedAfterStart,
+		}
+		// tasks still in the sprint, or taken out of it, are shown as they are now
+		if record.Outcome == "" || record.Outcome == models.SprintTaskRemoved {
+			task.Status, task.Estimate = string(record.Task.Status), record.Task.Estimate
+		}
+		if record.AddedAfterStart {
+			report.AddedAfterStart++
+		}
+
+		switch {
+		case record.Outcome == models.SprintTaskRemoved:
+			report.Removed.Add(task)
+		case record.Outcome == models.SprintTaskCompleted || (record.Outcome == "" && slices.Contains(doneStates, task.Status)):
+			report.Completed.Add(task)
+		default:
+			report.CarriedOver.Add(task)
+		}
+	}
+
+	return c.JSON(report)
+}
+
+// getSprint loads the sprint from the :id param and checks that the user is a member of its project.
+// When ok is false the error response has already been written.
+func getSprint(c *fiber.Ctx) (sprint models.Sprint, ok bool) {
+	if err := config.DB.First(&sprint, "id = ?", c.Params("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Sprint not found"})
+			return sprint, false
+		}
+		c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve sprint"})
+		return sprint, false
+	}
+
+	user := GetUserByID(c)
+	if !isProjectMember(c, sprint.ProjectID, user.ID) {
+		c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Sprint not found"})
+		return sprint, false
+	}
+
+	return sprint, true
+}
+
+// addToSprint records that the task joined the sprint, again when it was taken out before
+func addToSprint(tx *gorm.DB, sprint models.Sprint, taskID string) error {
+	record := models.SprintTask{SprintID: sprint.ID, TaskID: taskID, AddedAt: time.Now(), AddedAfterStart: sprint.State == models.SprintActive}
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "sprint_id"}, {Name: "task_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"outcome": "", "added_at": record.AddedAt, "added_after_start": record.AddedAfterStart}),
+	}).Create(&record).Error
+}
+
+// removeFromSprint records that the task left the sprint, a planned sprint forgets it instead
+func removeFromSprint(tx *gorm.DB, sprint models.Sprint, taskID string) error {
+	record := tx.Where("sprint_id = ? AND task_id = ?", sprint.ID, taskID)
+	if sprint.State == models.SprintPlanned {
+		return record.Delete(&models.SprintTask{}).Error
+	}
+	return record.Model(&models.SprintTask{}).Update("outcome", models.SprintTaskRemoved).Error
+}
+
+// moveToSprint sets the sprint of the tasks, nil being the backlog, and publishes the change
+func moveToSprint(tx *gorm.DB, taskIDs []string, from *models.Sprint, to *models.Sprint, actorID string) error {
+	if len(taskIDs) == 0 {
+		return nil
+	}
+	if err := tx.Model(&models.Task{}).Where("id IN ?", taskIDs).Updates(map[string]interface{}{"sprint_id": sprintID(to), "updated_by": actorID}).Error; err != nil {
+		return err
+	}
+
+	changes := map[string]map[string]string{"sprint": {"from": sprintName(from), "to": sprintName(to)}}
+	for _, taskID := range taskIDs {
+		if err := publishTaskUpdated(tx, taskID, actorID, changes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sprintID(sprint *models.Sprint) *string {
+	if sprint == nil {
+		return nil
+	}
+	return &sprint.ID
+}
+
+func sprintName(sprint *models.Sprint) string {
+	if sprint == nil {
+		return ""
+	}
+	return sprint.Name
+}
+
+// dispatchAll dispatches the events published for each task
+func dispatchAll(taskIDs []string) {
+	for _, taskID := range taskIDs {
+		events.Dispatch(taskID)
+	}
+}
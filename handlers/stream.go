@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"task-management-api/config"
+	"task-management-api/events"
+	"task-management-api/models"
+	"task-management-api/utils"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	streamBatchSize = 100
+	// how often the outbox is checked for events published by other instances
+	streamPollInterval = 2 * time.Second
+	streamHeartbeat    = 15 * time.Second
+	// how long a gap in the event ids is waited on, in case the transaction that took the id has not committed yet
+	streamGapWait = 5 * time.Second
+)
+
+// streamEvents maps the domain events sent to streams to their SSE event names
+var streamEvents = map[string]string{
+	models.TaskCreated:   "task.created",
+	models.TaskUpdated:   "task.updated",
+	models.TaskDeleted:   "task.deleted",
+	models.CommentAdded:  "comment.created",
+	models.CommentEdited: "comment.updated",
+}
+
+// Stream pushes task and comment events to the client as Server-Sent Events. Each event carries
+// its outbox id, a client that reconnects with Last-Event-ID (or ?last_event_id=) gets the events it missed.
+// The stream ends when the access token expires or is revoked.
+func Stream(c *fiber.Ctx) error {
+	user := GetUserByID(c)
+	seesAll := utils.RoleHasPermission(GetRole(c), utils.ProjectManageAny)
+
+	claims := c.Locals("user").(jwt.MapClaims)
+	jti, _ := claims["jti"].(string)
+	expires, err := claims.GetExpirationTime()
+	if err != nil || expires == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	lastID := c.Get("Last-Event-ID", c.Query("last_event_id"))
+	var last int64
+	if lastID != "" {
+		id, err := strconv.ParseInt(lastID, 10, 64)
+		if err != nil || id < 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid last event id"})
+		}
+		last = id
+	} else if err := config.DB.Model(&models.OutboxEvent{}).Select("COALESCE(MAX(id), 0)").Scan(&last).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to open stream"})
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		fmt.Fprintf(w, "retry: %d\n\n", streamPollInterval.Milliseconds())
+		if w.Flush() != nil {
+			return
+		}
+
+		lastWrite := time.Now()
+		for {
+			changed := events.Changed()
+
+			if time.Now().After(expires.Time) {
+				return
+			}
+			role, ok, err := streamTokenValid(jti, user.ID)
+			if err != nil {
+				log.Println("Failed to check stream token:", err)
+				return
+			}
+			if !ok {
+				return
+			}
+			seesAll = utils.RoleHasPermission(role, utils.ProjectManageAny)
+
+			sent, err := streamBatch(w, &last, user.ID, seesAll)
+			if err != nil {
+				log.Println("Failed to stream events:", err)
+				return
+			}
+			if sent {
+				if w.Flush() != nil {
+					return
+				}
+				lastWrite = time.Now()
+				continue
+			}
+
+			if time.Since(lastWrite) >= streamHeartbeat {
+				fmt.Fprint(w, ": ping\n\n")
+				if w.Flush() != nil {
+					return
+				}
+				lastWrite = time.Now()
+			}
+
+			select {
+			case <-changed:
+			case <-time.After(min(streamPollInterval, time.Until(expires.Time))):
+			}
+		}
+	})
+
+	return nil
+}
+
+// streamTokenValid checks again that the token of a stream has not been revoked by logout
+// and that its user still exists, and returns the user's current role
+func streamTokenValid(jti, userID string) (string, bool, error) {
+	var revoked int64
+	if err := config.DB.Model(&models.RevokedToken{}).Where("jti = ?", jti).Count(&revoked).Error; err != nil {
+		return "", false, err
+	}
+	if jti == "" || revoked > 0 {
+		return "", false, nil
+	}
+
+	var users []models.User
+	if err := config.DB.Select("id", "role").Where("id = ?", userID).Limit(1).Find(&users).Error; err != nil {
+		return "", false, err
+	}
+	if len(users) == 0 {
+		return "", false, nil
+	}
+	return users[0].Role, true, nil
+}
+
+// streamBatch writes the events after last that the user can see and moves last past them.
+// It returns whether it went through any event, so the caller reads the next batch right away.
+func streamBatch(w *bufio.Writer, last *int64, userID string, seesAll bool) (bool, error) {
+	var batch []models.OutboxEvent
+	if err := config.DB.Where("id > ?", *last).Order("id").Limit(streamBatchSize).Find(&batch).Error; err != nil {
+		return false, err
+	}
+
+	// Ids are taken when events are published but show up in commit order, stop at a
+	// recent gap so an event committed late is not skipped
+	for i, event := range batch {
+		if event.ID != *last+1 && time.Since(event.CreatedAt) < streamGapWait {
+			batch = batch[:i]
+			break
+		}
+		*last = event.ID
+	}
+	if len(batch) == 0 {
+		return false, nil
+	}
+
+	var memberOf []string
+	if !seesAll {
+		if err := config.DB.Model(&models.ProjectMember{}).Where("user_id = ?", userID).Pluck("project_id", &memberOf).Error; err != nil {
+			return false, err
+		}
+	}
+
+	for _, event := range batch {
+		name, ok := streamEvents[event.Type]
+		if !ok || (event.ProjectID != nil && !seesAll && !slices.Contains(memberOf, *event.ProjectID)) {
+			continue
+		}
+
+		data, err := streamData(event)
+		if err != nil {
+			return false, err
+		}
+		if data == nil {
+			continue
+		}
+		fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, name, data)
+	}
+	return true, nil
+}
+
+// streamData is the payload of task events, and the comment as it is now for comment events.
+// It returns nil when the comment no longer exists.
+func streamData(event models.OutboxEvent) ([]byte, error) {
+	if event.Type != models.CommentAdded && event.Type != models.CommentEdited {
+		return []byte(event.Payload), nil
+	}
+
+	var payload models.CommentEvent
+	if err := event.Decode(&payload); err != nil {
+		return nil, err
+	}
+	var comments []models.Comment
+	if err := config.DB.Preload("User").Preload("Mentions.User").Preload("Reactions.User").
+		Where("id = ?", payload.CommentID).Limit(1).Find(&comments).Error; err != nil {
+		return nil, err
+	}
+	if len(comments) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(models.FormatCommentResponse(comments[0]))
+}
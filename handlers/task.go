@@ -1,82 +1,53 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"log"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
 	"task-management-api/config"
+	"task-management-api/events"
+	"task-management-api/jobs"
 	"task-management-api/models"
 	"task-management-api/utils"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
 
-// GetAllTasks fetches all tasks with optional filters
-func GetAllTasks(c *fiber.Ctx) error {
-	var tasks []models.Task
-
-	pageQuery := c.Query("page", "1")          // Default to page 1
-	pageSizeQuery := c.Query("pageSize", "10") // Default to pageSize 10
-	// parse page and pageSize
-	page := utils.StrToInt(pageQuery)
-	pageSize := utils.StrToInt(pageSizeQuery)
-	title := c.Query("title", "")
-	status := c.Query("status", "")
-	assignee := c.Query("assignee", "")
-	createdBy := c.Query("createdBy", "")
+var taskKeyPattern = regexp.MustCompile(`^[A-Z][A-Z0-9]{1,9}-[0-9]+$`)
 
-	// Initialize query builder
-	query := config.DB.Model(&models.Task{})
+// GetAllTasks fetches the tasks the user can see with optional filters
+func GetAllTasks(c *fiber.Ctx) error {
+	return listTasks(c, visibleTasks(c, config.DB.Model(&models.Task{})), nil)
+}
 
-	// Apply filters based on query params
-	if status != "" {
-		query = query.Where("status = ?", status)
-	}
-	if createdBy != "" {
-		query = query.Where("created_by = ?", createdBy)
-	}
-	if title != "" {
-		query = query.Where("title LIKE ?", "%"+title+"%")
-	}
-	if assignee != "" {
-		query = query.Where("assignee = ?", assignee)
+// listTasks applies the common filters and pagination to a task query, scoped to projectID if not nil
+func listTasks(c *fiber.Ctx, query *gorm.DB, projectID *string) error {
+	filter, status, err := resolveTaskFilter(c)
+	if err != nil {
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	var count int64
-	query.Count(&count)
-
-	var totalPages int
-	if count%int64(pageSize) == 0 {
-		totalPages = int(count) / pageSize
-	} else {
-		totalPages = int(count)/pageSize + 1
+	if isCursorMode(c) && filter.Sort != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "sort is not supported with cursor pagination"})
 	}
 
-	// Apply pagination
-	offset := (page - 1) * pageSize
-	query = query.Offset(offset).Limit(pageSize)
-
-	// Execute query and fetch tasks
-	if err := query.Find(&tasks).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": "No tasks found"})
-		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve tasks"})
+	query, err = applyTaskFilter(c, query, filter, projectID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	// format each task and get total count
-	var data []interface{}
-	for _, task := range tasks {
-		data = append(data, models.FormatTaskResponse(task))
+	// Most urgent first, then soonest due
+	if !isCursorMode(c) && filter.Sort == "" {
+		query = query.Order(priorityRank + " DESC, tasks.due_date ASC NULLS LAST, tasks.id")
 	}
 
-	response := models.TransformPagination(&models.Pagination{
-		Page:       page,
-		PageSize:   pageSize,
-		Total:      int(count),
-		TotalPages: totalPages,
-		Data:       data,
-	})
-
-	return c.JSON(response)
+	return paginate(c, query.Preload("Labels").Preload("CoAssignees"), "tasks.updated_at", "tasks.id", taskCursor, taskFormatter(c))
 }
 
 func CreateTask(c *fiber.Ctx) error {
@@ -87,29 +58,152 @@ func CreateTask(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
 	}
 
+	// Tasks can only be added to projects the user is a member of
+	if task.ProjectID != nil && !isProjectMember(c, *task.ProjectID, user.ID) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not a member of this project"})
+	}
+
+	return createTask(c, task)
+}
+
+func createTask(c *fiber.Ctx, task models.Task) error {
+	user := GetUserByID(c)
+
 	task.CreatedBy = user.ID
 	task.UpdatedBy = user.ID
+	task.Key = nil
+	task.SprintID = nil
+
+	if task.ParentID != nil {
+		if err := validateParent(task, *task.ParentID); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	if err := validateSchedule(task.StartDate, task.DueDate, task.Estimate); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if task.Priority == "" {
+		task.Priority = utils.Medium
+	} else if !validatePriority(task.Priority) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Priority must be one of: LOWEST, LOW, MEDIUM, HIGH, HIGHEST"})
+	}
+	if task.Severity != nil && !validateSeverity(*task.Severity) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Severity must be one of: TRIVIAL, MINOR, MAJOR, CRITICAL, BLOCKER"})
+	}
+
+	if task.Type == "" {
+		task.Type = utils.PlainTask
+	}
+	problems := validateTaskType(task)
+	values, fieldProblems, err := validateCustomFields(task.ProjectID, nil, task.CustomFields, true)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve custom fields"})
+	}
+	maps.Copy(problems, fieldProblems)
+	if len(problems) > 0 {
+		return taskValidationError(c, problems)
+	}
+	task.CustomFields = values
+
+	labels, err := getTaskLabels(task, task.LabelIDs)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	task.Labels = labels
+
+	// assignee_ids sets every assignee, the first one becomes the primary assignee
+	var coAssignees []string
+	if task.AssigneeIDs != nil {
+		ids, err := validateAssignees(task.AssigneeIDs, task.ProjectID)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		task.Assignee = nil
+		if len(ids) > 0 {
+			task.Assignee, coAssignees = &ids[0], ids[1:]
+		}
+	} else if task.Assignee != nil && !validateAssignee(*task.Assignee, task.ProjectID) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Assignee must be a valid user ID, and a member of the task's project"})
+	}
+
+	// New tasks start in the workflow's initial state unless another state is given
+	workflow, err := getWorkflow(task.ProjectID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve workflow"})
+	}
+	if task.Status == "" {
+		task.Status = utils.Status(workflow.InitialState)
+	} else if !validateStatus(workflow, string(task.Status)) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Status must be one of: " + strings.Join(workflow.StateNames(), ", ")})
+	}
+
+	// Create the task at the end of its board column, issuing its key within the same transaction
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if task.ProjectID != nil {
+			if err := assignTaskKey(tx, &task); err != nil {
+				return err
+			}
+		}
+		rank, err := placeTask(tx, task, task.Status, "", "")
+		if err != nil {
+			return err
+		}
+		task.Rank = rank
+		if err := tx.Create(&task).Error; err != nil {
+			return err
+		}
+		if err := setCoAssignees(tx, task.ID, coAssignees); err != nil {
+			return err
+		}
+
+		for _, userID := range coAssignees {
+			task.CoAssignees = append(task.CoAssignees, models.TaskAssignee{TaskID: task.ID, UserID: userID})
+		}
+
+		// The creator and the assignees follow the task
+		watchers := append([]string{task.CreatedBy}, coAssignees...)
+		if task.Assignee != nil {
+			watchers = append(watchers, *task.Assignee)
+		}
+		if err := addWatchers(tx, task.ID, watchers...); err != nil {
+			return err
+		}
 
-	// Create the task
-	if err := config.DB.Preload("CreatedUser").Create(&task).Error; err != nil {
+		if err := publishTaskEvent(tx, models.TaskCreated, task, user.ID, nil); err != nil {
+			return err
+		}
+		if assignees := models.TaskAssigneeIDs(task); len(assignees) > 0 {
+			return events.Publish(tx, models.TaskAssigned, task, user.ID, models.TaskAssignedEvent{UserIDs: assignees})
+		}
+		return nil
+	})
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create task"})
 	}
+	events.Dispatch(task.ID)
+
+	refreshOverdue(&task)
 
-	response := models.FormatTaskResponse(task)
+	response := renderTask(c, models.FormatTaskResponse(task))
 
 	return c.Status(fiber.StatusCreated).JSON(response)
 }
 
 func GetTaskById(c *fiber.Ctx) error {
-	taskID := c.Params("id")
+	task, ok := getVisibleTask(c)
+	if !ok {
+		return nil
+	}
 
-	taskDetails, err := getTaskWithDetails(taskID)
+	taskDetails, err := getTaskWithDetails(c, task.ID)
 
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve task"})
 	}
 
-	return c.JSON(taskDetails)
+	return c.JSON(renderTaskDetails(c, taskDetails))
 }
 
 func UpdateTask(c *fiber.Ctx) error {
@@ -124,51 +218,254 @@ func UpdateTask(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve task"})
 	}
 
+	if !canViewTask(c, task) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Task not found"})
+	}
+
+	// Check if the task belongs to the authenticated user or the user can update any task
+	if !canUpdateTask(c, task, user.ID) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not authorized to update this task"})
+	}
+
+	// remember who was assigned to notify only the new assignees
+	config.DB.Where("task_id = ?", task.ID).Find(&task.CoAssignees)
+	previousAssignees := models.TaskAssigneeIDs(task)
+
 	// Parse the update data
 	var updatedTask models.Task
 	if err := c.BodyParser(&updatedTask); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
 	}
 
-	// validate status if status exists
-	if updatedTask.Status != "" && !validateStatus(string(updatedTask.Status)) {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Status must be one of: TODO, IN_PROGRESS, DONE"})
+	// validate the status change against the task's workflow
+	if updatedTask.Status != "" {
+		if status, err := checkStatusChange(task, updatedTask.Status, GetRole(c)); err != nil {
+			return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+		}
 	}
 
-	payload := models.Task{
-		Title:       updatedTask.Title,
-		Description: updatedTask.Description,
-		Status:      updatedTask.Status,
-		UpdatedBy:   user.ID,
+	// validate parent if parent exists in payload, empty string removes it
+	if updatedTask.ParentID != nil && *updatedTask.ParentID != "" {
+		if err := validateParent(task, *updatedTask.ParentID); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
 	}
 
-	updatedTask.UpdatedBy = user.ID // for history
+	if updatedTask.Priority != "" && !validatePriority(updatedTask.Priority) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Priority must be one of: LOWEST, LOW, MEDIUM, HIGH, HIGHEST"})
+	}
+	if updatedTask.Severity != nil && !validateSeverity(*updatedTask.Severity) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Severity must be one of: TRIVIAL, MINOR, MAJOR, CRITICAL, BLOCKER"})
+	}
 
-	// Update history before updating the task
-	if err := AddHistory(taskID, updatedTask); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update history"})
+	// start_date, due_date, estimate and severity are removed by sending null
+	cleared := nullFields(c, "start_date", "due_date", "estimate", "severity")
+	startDate, dueDate := task.StartDate, task.DueDate
+	if updatedTask.StartDate != nil || slices.Contains(cleared, "start_date") {
+		startDate = updatedTask.StartDate
+	}
+	if updatedTask.DueDate != nil || slices.Contains(cleared, "due_date") {
+		dueDate = updatedTask.DueDate
+	}
+	if err := validateSchedule(startDate, dueDate, updatedTask.Estimate); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	// validate assignee if assignee exists in payload
-	if updatedTask.Assignee != nil {
-		// remove assignee if assignee from payload is empty string
-		if *updatedTask.Assignee == "" {
-			if err := config.DB.Model(&task).Update("assignee", nil).Error; err != nil {
-				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update assignee to null"})
-			}
-		} else if !validateAssignee(*updatedTask.Assignee) {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Assignee must be a valid user ID"})
-		} else {
-			payload.Assignee = updatedTask.Assignee
+	// check the type rules against the task as it will be after the update,
+	// a task that stops being a bug loses its severity and steps to reproduce
+	effective := task
+	if updatedTask.Type != "" {
+		effective.Type = updatedTask.Type
+	}
+	leavingBug := task.Type == utils.Bug && effective.Type != utils.Bug
+	if leavingBug {
+		effective.Severity = nil
+		effective.StepsToReproduce = ""
+	}
+	if updatedTask.StepsToReproduce != "" {
+		effective.StepsToReproduce = updatedTask.StepsToReproduce
+	}
+	if updatedTask.Severity != nil || slices.Contains(cleared, "severity") {
+		effective.Severity = updatedTask.Severity
+	}
+	if updatedTask.ParentID != nil {
+		effective.ParentID = updatedTask.ParentID
+		if *updatedTask.ParentID == "" {
+			effective.ParentID = nil
 		}
 	}
+	problems := validateTaskType(effective)
+	// tasks from before task types may carry a severity, it only counts when the request sets the type or the field
+	if effective.Type != utils.Bug && updatedTask.Type == "" {
+		if updatedTask.Severity == nil {
+			delete(problems, "severity")
+		}
+		if updatedTask.StepsToReproduce == "" {
+			delete(problems, "steps_to_reproduce")
+		}
+	}
+	if updatedTask.CustomFields != nil {
+		values, fieldProblems, err := validateCustomFields(task.ProjectID, task.CustomFields, updatedTask.CustomFields, false)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve custom fields"})
+		}
+		maps.Copy(problems, fieldProblems)
+		updatedTask.CustomFields = values
+	}
+	if len(problems) > 0 {
+		return taskValidationError(c, problems)
+	}
+	if leavingBug && task.Severity != nil && !slices.Contains(cleared, "severity") {
+		cleared = append(cleared, "severity")
+	}
+
+	// label_ids replaces the task's labels when present
+	var labels []models.Label
+	if updatedTask.LabelIDs != nil {
+		var err error
+		if labels, err = getTaskLabels(task, updatedTask.LabelIDs); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	// assignee_ids replaces every assignee, the first one becoming the primary assignee
+	var coAssignees []string
+	if updatedTask.AssigneeIDs != nil {
+		ids, err := validateAssignees(updatedTask.AssigneeIDs, task.ProjectID)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		primary := ""
+		if len(ids) > 0 {
+			primary, coAssignees = ids[0], ids[1:]
+		}
+		updatedTask.Assignee = &primary
+		updatedTask.AssigneeIDs = ids
+	}
+
+	payload := models.Task{
+		Type:             updatedTask.Type,
+		Title:            updatedTask.Title,
+		Description:      updatedTask.Description,
+		StepsToReproduce: updatedTask.StepsToReproduce,
+		CustomFields:     updatedTask.CustomFields,
+		Status:           updatedTask.Status,
+		Priority:         updatedTask.Priority,
+		Severity:         updatedTask.Severity,
+		StartDate:        updatedTask.StartDate,
+		DueDate:          updatedTask.DueDate,
+		Estimate:         updatedTask.Estimate,
+		UpdatedBy:        user.ID,
+	}
+
+	// validate assignee if assignee exists in payload, empty string removes it
+	if updatedTask.Assignee != nil && *updatedTask.Assignee != "" {
+		if !validateAssignee(*updatedTask.Assignee, task.ProjectID) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Assignee must be a valid user ID, and a member of the task's project"})
+		}
+		payload.Assignee = updatedTask.Assignee
+	}
+	if updatedTask.ParentID != nil && *updatedTask.ParentID != "" {
+		payload.ParentID = updatedTask.ParentID
+	}
+
+	// New assignees follow the task, those who were not assigned before are notified
+	newAssignees := coAssignees
+	if payload.Assignee != nil {
+		newAssignees = append(newAssignees, *payload.Assignee)
+	}
+	var assigned []string
+	for _, userID := range newAssignees {
+		if !slices.Contains(previousAssignees, userID) {
+			assigned = append(assigned, userID)
+		}
+	}
+
+	updatedTask.UpdatedBy = user.ID
+
+	// Update the task and publish the change in one transaction, the history
+	// is recorded from the published changes
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		changes, err := taskChanges(tx, task.ID, updatedTask, cleared...)
+		if err != nil {
+			return err
+		}
+
+		// a task changing status goes to the end of its new column
+		if updatedTask.Status != "" && updatedTask.Status != task.Status {
+			if payload.Rank, err = placeTask(tx, task, updatedTask.Status, "", ""); err != nil {
+				return err
+			}
+		}
+
+		if updatedTask.Assignee != nil && *updatedTask.Assignee == "" {
+			if err := tx.Model(&task).Update("assignee", nil).Error; err != nil {
+				return err
+			}
+		}
+		if updatedTask.AssigneeIDs != nil {
+			if err := setCoAssignees(tx, task.ID, coAssignees); err != nil {
+				return err
+			}
+		} else if payload.Assignee != nil {
+			// the new primary assignee is no longer one of the other assignees
+			if err := tx.Where("task_id = ? AND user_id = ?", task.ID, *payload.Assignee).Delete(&models.TaskAssignee{}).Error; err != nil {
+				return err
+			}
+		}
+		if err := addWatchers(tx, task.ID, newAssignees...); err != nil {
+			return err
+		}
+
+		if updatedTask.ParentID != nil && *updatedTask.ParentID == "" {
+			if err := tx.Model(&task).Update("parent_id", nil).Error; err != nil {
+				return err
+			}
+		}
+
+		if len(cleared) > 0 {
+			nulls := map[string]interface{}{}
+			for _, field := range cleared {
+				nulls[field] = nil
+			}
+			if err := tx.Model(&task).Updates(nulls).Error; err != nil {
+				return err
+			}
+		}
+
+		if updatedTask.LabelIDs != nil {
+			if err := tx.Model(&task).Association("Labels").Replace(labels); err != nil {
+				return err
+			}
+		}
 
-	// Update the task
-	if err := config.DB.Model(&task).Updates(payload).Error; err != nil {
+		if leavingBug {
+			if err := tx.Model(&task).Update("steps_to_reproduce", "").Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&task).Updates(payload).Error; err != nil {
+			return err
+		}
+
+		if err := publishTaskUpdated(tx, task.ID, user.ID, changes); err != nil {
+			return err
+		}
+		if len(assigned) > 0 {
+			return events.Publish(tx, models.TaskAssigned, task, user.ID, models.TaskAssignedEvent{UserIDs: assigned})
+		}
+		return nil
+	})
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update task"})
 	}
+	events.Dispatch(task.ID)
+
+	refreshOverdue(&task)
+	config.DB.Where("task_id = ?", task.ID).Find(&task.CoAssignees)
 
-	response := models.FormatTaskResponse(task)
+	response := renderTask(c, models.FormatTaskResponse(task))
 
 	return c.JSON(response)
 }
@@ -184,47 +481,119 @@ func DeleteTask(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve task"})
 	}
 
-	// Check if the task belongs to the authenticated user
+	if !canViewTask(c, task) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Task not found"})
+	}
+
+	// Check if the task belongs to the authenticated user or the user can delete any task
 	user := GetUserByID(c)
-	if !utils.HasPermission(task.CreatedBy, user.ID) {
+	if !utils.HasPermission(GetRole(c), utils.TaskDeleteAny, task.CreatedBy, user.ID) {
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not authorized to delete this task"})
 	}
 
-	// Delete associated comments
-	if err := config.DB.Table("comments").Where("task_id = ?", taskID).Delete(&models.Comment{}).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete comments before deleting task"})
-	}
+	// Delete the task with everything attached to it, and publish the deletion, in one transaction
+	var attachmentKeys []string
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		// The event keeps the task as it was
+		if err := tx.Preload("Labels").Preload("CoAssignees").First(&task, "id = ?", taskID).Error; err != nil {
+			return err
+		}
+		if err := publishTaskEvent(tx, models.TaskDeleted, task, user.ID, nil); err != nil {
+			return err
+		}
 
-	// Delete associated histories
-	if err := config.DB.Table("histories").Where("task_id = ?", taskID).Delete(&models.History{}).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete history before deleting task"})
-	}
+		// Delete the attachments of the task and its comments, their files are removed once the task is gone
+		var err error
+		if attachmentKeys, err = deleteAttachments(tx, "task_id = ?", taskID); err != nil {
+			return err
+		}
+
+		// Delete associated comments with their mentions and reactions
+		comments := tx.Model(&models.Comment{}).Select("id").Where("task_id = ?", taskID)
+		if err := tx.Where("comment_id IN (?)", comments).Delete(&models.CommentMention{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("comment_id IN (?)", comments).Delete(&models.CommentReaction{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Table("comments").Where("task_id = ?", taskID).Delete(&models.Comment{}).Error; err != nil {
+			return err
+		}
 
-	// Delete the task
-	if err := config.DB.Delete(&task).Error; err != nil {
+		// Delete associated links and detach subtasks
+		if err := tx.Where("source_id = ? OR target_id = ?", taskID, taskID).Delete(&models.TaskLink{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Task{}).Where("parent_id = ?", taskID).Update("parent_id", nil).Error; err != nil {
+			return err
+		}
+
+		// Delete associated notifications
+		if err := tx.Where("task_id = ?", taskID).Delete(&models.Notification{}).Error; err != nil {
+			return err
+		}
+
+		// Remove the task's assignees, watchers, labels and sprint records
+		if err := tx.Where("task_id = ?", taskID).Delete(&models.TaskAssignee{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("task_id = ?", taskID).Delete(&models.SprintTask{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("task_id = ?", taskID).Delete(&models.TaskWatcher{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&task).Association("Labels").Clear(); err != nil {
+			return err
+		}
+
+		// Delete associated histories
+		if err := tx.Table("histories").Where("task_id = ?", taskID).Delete(&models.History{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(&task).Error
+	})
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete task"})
 	}
+	removeFiles(attachmentKeys...)
+	events.Dispatch(task.ID)
 
 	return c.Status(fiber.StatusOK).SendString("Task deleted")
 }
 
-// return true if stutus is in type enum utils.Status
-func validateStatus(status string) bool {
-	return status == string(utils.Todo) || status == string(utils.InProgress) || status == string(utils.Done) || status == string(utils.Archive)
-}
-
-func getTaskWithDetails(taskID string) (models.TaskDetailsResponse, error) {
+func getTaskWithDetails(c *fiber.Ctx, taskID string) (models.TaskDetailsResponse, error) {
 	var task models.Task
 	var comments []models.Comment
 	var history []models.History
 
-	// Fetch the task by ID
-	if err := config.DB.Preload("CreatedUser").Preload("UpdatedUser").Preload("AssigneeUser").First(&task, "id = ?", taskID).Error; err != nil {
+	// Fetch the task by ID or by key
+	if err := config.DB.Preload("CreatedUser").Preload("UpdatedUser").Preload("AssigneeUser").Preload("Labels").Preload("CoAssignees").Where(taskIDCondition(taskID)).First(&task).Error; err != nil {
 		return models.TaskDetailsResponse{}, err
 	}
+	taskID = task.ID
 
 	// Fetch the comments for the task
-	if err := config.DB.Preload("User").Where("task_id = ?", taskID).Find(&comments).Error; err != nil {
+	if err := config.DB.Preload("User").Preload("Mentions.User").Preload("Reactions.User").Where("task_id = ?", taskID).Order("created_at, id").Find(&comments).Error; err != nil {
+		return models.TaskDetailsResponse{}, err
+	}
+
+	// Fetch the subtasks and links
+	var subtasks []models.Task
+	if err := visibleTasks(c, config.DB).Where("parent_id = ?", taskID).Find(&subtasks).Error; err != nil {
+		return models.TaskDetailsResponse{}, err
+	}
+	links, err := getTaskLinks(c, taskID)
+	if err != nil {
+		return models.TaskDetailsResponse{}, err
+	}
+	watchers, err := getTaskWatchers(taskID)
+	if err != nil {
+		return models.TaskDetailsResponse{}, err
+	}
+	attachments, err := getTaskAttachments(taskID)
+	if err != nil {
 		return models.TaskDetailsResponse{}, err
 	}
 
@@ -233,12 +602,22 @@ func getTaskWithDetails(taskID string) (models.TaskDetailsResponse, error) {
 		return models.TaskDetailsResponse{}, err
 	}
 
-	var commentsResponse []models.CommentResponse
-	var historyResponse []models.HistoryResponse
-	// Format the comments
-	for _, comment := range comments {
-		commentsResponse = append(commentsResponse, models.FormatCommentResponse(comment))
+	// Epics report how far along their children are
+	var progress *models.TaskProgress
+	if task.Type == utils.Epic {
+		if progress, err = epicProgress(subtasks); err != nil {
+			return models.TaskDetailsResponse{}, err
+		}
 	}
+
+	subtasksResponse := []models.LinkedTaskResponse{}
+	for _, subtask := range subtasks {
+		subtasksResponse = append(subtasksResponse, models.FormatLinkedTaskResponse(subtask))
+	}
+
+	var historyResponse []models.HistoryResponse
+	// Format the comments as threads
+	commentsResponse := commentThreads(comments)
 	// Format the history
 	for _, history := range history {
 		h, err := models.FormatHistoryResponse(history)
@@ -248,6 +627,7 @@ func getTaskWithDetails(taskID string) (models.TaskDetailsResponse, error) {
 		historyResponse = append(historyResponse, h)
 	}
 
+	assignees := models.TaskAssigneeIDs(task)
 	createdBy := task.CreatedBy
 	updatedBy := task.UpdatedBy
 	assignee := task.Assignee
@@ -262,25 +642,168 @@ func getTaskWithDetails(taskID string) (models.TaskDetailsResponse, error) {
 	}
 	// Format the response
 	response := models.TaskDetailsResponse{
-		ID:          task.ID,
-		Title:       task.Title,
-		Status:      string(task.Status),
-		Description: task.Description,
-		CreatedAt:   task.CreatedAt,
-		UpdatedAt:   task.UpdatedAt,
-		CreatedBy:   createdBy,
-		UpdatedBy:   updatedBy,
-		Assignee:    assignee,
-		Comments:    commentsResponse,
-		History:     historyResponse,
+		ID:               task.ID,
+		ProjectID:        task.ProjectID,
+		Key:              task.Key,
+		ParentID:         task.ParentID,
+		Type:             string(task.Type),
+		Title:            task.Title,
+		StepsToReproduce: task.StepsToReproduce,
+		Status:           string(task.Status),
+		Priority:         string(task.Priority),
+		Severity:         task.Severity,
+		Description:      task.Description,
+		CreatedAt:        task.CreatedAt,
+		UpdatedAt:        task.UpdatedAt,
+		CreatedBy:        createdBy,
+		UpdatedBy:        updatedBy,
+		Assignee:         assignee,
+		Assignees:        assignees,
+		StartDate:        task.StartDate,
+		DueDate:          task.DueDate,
+		Estimate:         task.Estimate,
+		Overdue:          task.Overdue,
+		Labels:           models.FormatLabelsResponse(task.Labels),
+		CustomFields:     task.CustomFields,
+		Progress:         progress,
+		Watchers:         watchers,
+		Subtasks:         subtasksResponse,
+		Links:            links,
+		Comments:         commentsResponse,
+		Attachments:      attachments,
+		History:          historyResponse,
 	}
 
 	return response, nil
 }
 
-func validateAssignee(userID string) bool {
-	if err := config.DB.First(&models.User{}, "id = ?", userID).Error; err != nil {
-		return false
+// return true if the user created the task, is one of its assignees, or can update any task
+func canUpdateTask(c *fiber.Ctx, task models.Task, userID string) bool {
+	if task.Assignee != nil && *task.Assignee == userID {
+		return true
+	}
+	if isCoAssignee(task.ID, userID) {
+		return true
+	}
+	return utils.HasPermission(GetRole(c), utils.TaskUpdateAny, task.CreatedBy, userID)
+}
+
+// canViewTaskID is canViewTask for the task with the ID, e.g. the task of a comment or an attachment
+func canViewTaskID(c *fiber.Ctx, taskID string) (bool, error) {
+	var task models.Task
+	if err := config.DB.Select("id", "project_id").First(&task, "id = ?", taskID).Error; err != nil {
+		return false, err
+	}
+	return canViewTask(c, task), nil
+}
+
+// getVisibleTask loads the task from the :id param, by ID or key, and checks that the user can see it.
+// When ok is false the error response has already been written.
+func getVisibleTask(c *fiber.Ctx) (task models.Task, ok bool) {
+	if err := config.DB.Where(taskIDCondition(c.Params("id"))).First(&task).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Task not found"})
+			return task, false
+		}
+		c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve task"})
+		return task, false
 	}
-	return true
+
+	if !canViewTask(c, task) {
+		c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Task not found"})
+		return task, false
+	}
+
+	return task, true
+}
+
+// canViewTask reports whether the user, who may not be authenticated, can see the task.
+// Tasks without a project are public, project tasks are visible to the project members.
+func canViewTask(c *fiber.Ctx, task models.Task) bool {
+	if task.ProjectID == nil {
+		return true
+	}
+	userID := currentUserID(c)
+	return userID != "" && isProjectMember(c, *task.ProjectID, userID)
+}
+
+// visibleTasks limits a task query to the tasks the user can see
+func visibleTasks(c *fiber.Ctx, query *gorm.DB) *gorm.DB {
+	if utils.RoleHasPermission(GetRole(c), utils.ProjectManageAny) {
+		return query
+	}
+	userID := currentUserID(c)
+	if userID == "" {
+		return query.Where("tasks.project_id IS NULL")
+	}
+	memberOf := config.DB.Model(&models.ProjectMember{}).Select("project_id").Where("user_id = ?", userID)
+	return query.Where("tasks.project_id IS NULL OR tasks.project_id IN (?)", memberOf)
+}
+
+// taskIDCondition matches a task by its key (e.g. WEB-12) or by its ID
+func taskIDCondition(idOrKey string) map[string]interface{} {
+	if taskKeyPattern.MatchString(strings.ToUpper(idOrKey)) {
+		return map[string]interface{}{"key": strings.ToUpper(idOrKey)}
+	}
+	return map[string]interface{}{"id": idOrKey}
+}
+
+func taskCursor(task models.Task) utils.Cursor {
+	return utils.Cursor{Time: task.UpdatedAt, ID: task.ID}
+}
+
+// nullFields returns which of the given fields the JSON body explicitly sets to null
+func nullFields(c *fiber.Ctx, fields ...string) []string {
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(c.Body(), &body); err != nil {
+		return nil
+	}
+
+	var cleared []string
+	for _, field := range fields {
+		if value, ok := body[field]; ok && string(value) == "null" {
+			cleared = append(cleared, field)
+		}
+	}
+	return cleared
+}
+
+// return true if priority is one of the task priorities
+func validatePriority(priority utils.Priority) bool {
+	return slices.Contains(utils.Priorities, priority)
+}
+
+// return true if severity is one of the bug severities
+func validateSeverity(severity utils.Severity) bool {
+	return slices.Contains(utils.Severities, severity)
+}
+
+func validateSchedule(startDate *time.Time, dueDate *time.Time, estimate *int) error {
+	if startDate != nil && dueDate != nil && dueDate.Before(*startDate) {
+		return errors.New("Due date must not be before start date")
+	}
+	if estimate != nil && *estimate < 0 {
+		return errors.New("Estimate must not be negative")
+	}
+	return nil
+}
+
+// refreshOverdue recomputes the overdue flag right away instead of waiting for the job
+func refreshOverdue(task *models.Task) {
+	if err := jobs.RefreshOverdue(task.ID); err != nil {
+		log.Println("Failed to flag overdue task:", err)
+		return
+	}
+	config.DB.Select("overdue").First(task, "id = ?", task.ID)
+}
+
+// validateAssignee checks that the user exists and, for a project task, is a member of the project
+func validateAssignee(userID string, projectID *string) bool {
+	query := config.DB.Model(&models.User{}).Where("id = ?", userID)
+	if projectID != nil {
+		query = query.Where("id IN (?)", config.DB.Model(&models.ProjectMember{}).Select("user_id").Where("project_id = ?", *projectID))
+	}
+
+	var count int64
+	return query.Count(&count).Error == nil && count > 0
 }
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"slices"
+	"strings"
+	"task-management-api/models"
+	"task-management-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// validateTaskType checks the fields the task's type requires or forbids.
+// It returns the problems keyed by field, empty when the task is valid.
+func validateTaskType(task models.Task) map[string]string {
+	problems := map[string]string{}
+
+	if !slices.Contains(utils.TaskTypes, task.Type) {
+		problems["type"] = "must be one of: TASK, BUG, STORY, EPIC, CHORE"
+		return problems
+	}
+
+	// Bugs need enough detail to be triaged
+	if task.Type == utils.Bug {
+		if strings.TrimSpace(task.StepsToReproduce) == "" {
+			problems["steps_to_reproduce"] = "is required for bugs"
+		}
+		if task.Severity == nil {
+			problems["severity"] = "is required for bugs"
+		}
+	} else {
+		if task.StepsToReproduce != "" {
+			problems["steps_to_reproduce"] = "is only allowed for bugs"
+		}
+		if task.Severity != nil {
+			problems["severity"] = "is only allowed for bugs"
+		}
+	}
+
+	// Epics sit at the top, owning stories and other work
+	if task.Type == utils.Epic && task.ParentID != nil {
+		problems["parent_id"] = "epics cannot have a parent"
+	}
+
+	return problems
+}
+
+// taskValidationError responds with the problems found by validateTaskType
+func taskValidationError(c *fiber.Ctx, problems map[string]string) error {
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid task fields", "fields": problems})
+}
+
+// epicProgress rolls up how many children of an epic are in a done state
+func epicProgress(children []models.Task) (*models.TaskProgress, error) {
+	progress := &models.TaskProgress{Total: len(children)}
+
+	workflows := workflowCache{}
+	for _, child := range children {
+		done, err := workflows.isDone(child)
+		if err != nil {
+			return nil, err
+		}
+		if done {
+			progress.Done++
+		}
+	}
+
+	if progress.Total > 0 {
+		progress.Percent = progress.Done * 100 / progress.Total
+	}
+
+	return progress, nil
+}
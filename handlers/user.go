@@ -3,11 +3,17 @@ package handlers
 import (
 	"task-management-api/config"
 	"task-management-api/models"
+	"task-management-api/utils"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
 )
 
+type UpdateRoleRequest struct {
+	Role string `json:"role"`
+}
+
 func GetUserByID(c *fiber.Ctx) models.User {
 	claims := c.Locals("user").(jwt.MapClaims)
 	userID := claims["user_id"].(string)
@@ -21,3 +27,52 @@ func GetUserByID(c *fiber.Ctx) models.User {
 	}
 	return user
 }
+
+// GetRole returns the role loaded by AuthMiddleware
+func GetRole(c *fiber.Ctx) string {
+	role, _ := c.Locals("role").(string)
+	return role
+}
+
+// GetAllUsers lists users for user managers
+func GetAllUsers(c *fiber.Ctx) error {
+	var users []models.User
+	if err := config.DB.Order("email").Find(&users).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve users"})
+	}
+
+	response := []models.UserResponse{}
+	for _, user := range users {
+		response = append(response, models.FormatUserResponse(user))
+	}
+
+	return c.JSON(response)
+}
+
+// UpdateUserRole changes the role of a user
+func UpdateUserRole(c *fiber.Ctx) error {
+	userID := c.Params("id")
+
+	var req UpdateRoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	if !utils.ValidateRole(req.Role) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Role must be one of: admin, user"})
+	}
+
+	var user models.User
+	if err := config.DB.First(&user, "id = ?", userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve user"})
+	}
+
+	if err := config.DB.Model(&user).Update("role", req.Role).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update role"})
+	}
+	user.Role = req.Role
+
+	return c.JSON(models.FormatUserResponse(user))
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"errors"
+	"slices"
+	"task-management-api/config"
+	"task-management-api/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetTaskWatchers lists the users following a task
+func GetTaskWatchers(c *fiber.Ctx) error {
+	task, ok := getVisibleTask(c)
+	if !ok {
+		return nil
+	}
+
+	watchers, err := getTaskWatchers(task.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve watchers"})
+	}
+
+	return c.JSON(watchers)
+}
+
+// WatchTask makes the user follow a task
+func WatchTask(c *fiber.Ctx) error {
+	user := GetUserByID(c)
+
+	var task models.Task
+	if err := config.DB.Where(taskIDCondition(c.Params("id"))).First(&task).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Task not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve task"})
+	}
+
+	// Project tasks can only be watched by the project members
+	if task.ProjectID != nil && !isProjectMember(c, *task.ProjectID, user.ID) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not a member of this project"})
+	}
+
+	if err := addWatchers(config.DB, task.ID, user.ID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to watch task"})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.TaskWatcher{TaskID: task.ID, UserID: user.ID})
+}
+
+// UnwatchTask stops the user following a task
+func UnwatchTask(c *fiber.Ctx) error {
+	user := GetUserByID(c)
+
+	var task models.Task
+	if err := config.DB.Where(taskIDCondition(c.Params("id"))).First(&task).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Task not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve task"})
+	}
+
+	if err := config.DB.Where("task_id = ? AND user_id = ?", task.ID, user.ID).Delete(&models.TaskWatcher{}).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to unwatch task"})
+	}
+
+	return c.Status(fiber.StatusOK).SendString("Task unwatched")
+}
+
+func getTaskWatchers(taskID string) ([]models.UserResponse, error) {
+	var watchers []models.TaskWatcher
+	if err := config.DB.Preload("User").Where("task_id = ?", taskID).Order("created_at").Find(&watchers).Error; err != nil {
+		return nil, err
+	}
+
+	response := []models.UserResponse{}
+	for _, watcher := range watchers {
+		response = append(response, models.FormatUserResponse(watcher.User))
+	}
+	return response, nil
+}
+
+// addWatchers makes the users follow the task, skipping those already watching
+func addWatchers(db *gorm.DB, taskID string, userIDs ...string) error {
+	var watchers []models.TaskWatcher
+	for _, userID := range userIDs {
+		if userID != "" {
+			watchers = append(watchers, models.TaskWatcher{TaskID: taskID, UserID: userID})
+		}
+	}
+	if len(watchers) == 0 {
+		return nil
+	}
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&watchers).Error
+}
+
+// validateAssignees removes duplicates and checks that every ID is a user, a member of the project if any
+func validateAssignees(userIDs []string, projectID *string) ([]string, error) {
+	ids := []string{}
+	for _, id := range userIDs {
+		if slices.Contains(ids, id) {
+			continue
+		}
+		if !validateAssignee(id, projectID) {
+			return nil, errors.New("Assignees must be valid user IDs, and members of the task's project")
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// setCoAssignees replaces the assignees of the task besides its primary assignee
+func setCoAssignees(db *gorm.DB, taskID string, userIDs []string) error {
+	if err := db.Where("task_id = ?", taskID).Delete(&models.TaskAssignee{}).Error; err != nil {
+		return err
+	}
+
+	var assignees []models.TaskAssignee
+	for _, userID := range userIDs {
+		assignees = append(assignees, models.TaskAssignee{TaskID: taskID, UserID: userID})
+	}
+	if len(assignees) == 0 {
+		return nil
+	}
+	return db.Create(&assignees).Error
+}
+
+// return true if the user is one of the task's other assignees
+func isCoAssignee(taskID string, userID string) bool {
+	var count int64
+	config.DB.Model(&models.TaskAssignee{}).Where("task_id = ? AND user_id = ?", taskID, userID).Count(&count)
+	return count > 0
+}
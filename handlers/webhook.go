@@ -0,0 +1,361 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/url"
+	"slices"
+	"strings"
+	"task-management-api/config"
+	"task-management-api/jobs"
+	"task-management-api/models"
+	"task-management-api/utils"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type WebhookRequest struct {
+	URL        string   `json:"url"`
+	Events     []string `json:"events"`
+	ProjectKey string   `json:"project_key"` // on create, leave empty for a personal webhook
+	Active     *bool    `json:"active"`
+	Secret     string   `json:"secret"` // generated when empty on create, rotates it on update
+}
+
+// GetWebhooks lists the user's webhooks
+func GetWebhooks(c *fiber.Ctx) error {
+	user := GetUserByID(c)
+
+	var webhooks []models.Webhook
+	if err := config.DB.Preload("Project").Where("user_id = ?", user.ID).Order("created_at").Find(&webhooks).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve webhooks"})
+	}
+
+	response := []models.WebhookResponse{}
+	for _, webhook := range webhooks {
+		response = append(response, models.FormatWebhookResponse(webhook))
+	}
+
+	return c.JSON(response)
+}
+
+func GetWebhook(c *fiber.Ctx) error {
+	webhook, ok := getOwnedWebhook(c)
+	if !ok {
+		return nil
+	}
+
+	return c.JSON(models.FormatWebhookResponse(webhook))
+}
+
+// CreateWebhook subscribes a URL to events. Project webhooks can only be created by the project's owner.
+// The secret is only returned here and when it is rotated.
+func CreateWebhook(c *fiber.Ctx) error {
+	user := GetUserByID(c)
+
+	var req WebhookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	if msg := validateWebhook(req.URL, req.Events); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": msg})
+	}
+
+	webhook := models.Webhook{
+		UserID: user.ID,
+		URL:    req.URL,
+		Events: req.Events,
+		Secret: req.Secret,
+		Active: req.Active == nil || *req.Active,
+	}
+
+	if req.ProjectKey != "" {
+		var project models.Project
+		if err := config.DB.First(&project, "key = ?", strings.ToUpper(req.ProjectKey)).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Project not found"})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve project"})
+		}
+		if !utils.HasPermission(GetRole(c), utils.ProjectManageAny, project.OwnerID, user.ID) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not authorized to manage this project"})
+		}
+		webhook.ProjectID = &project.ID
+		webhook.Project = project
+	}
+
+	if webhook.Secret == "" {
+		secret, err := utils.RandomToken(32)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to generate secret"})
+		}
+		webhook.Secret = secret
+	}
+
+	if err := config.DB.Omit("Project").Create(&webhook).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create webhook"})
+	}
+
+	response := models.FormatWebhookResponse(webhook)
+	response.Secret = webhook.Secret
+
+	return c.Status(fiber.StatusCreated).JSON(response)
+}
+
+// UpdateWebhook changes the URL, events or active flag, or rotates the secret. The project cannot change.
+func UpdateWebhook(c *fiber.Ctx) error {
+	webhook, ok := getOwnedWebhook(c)
+	if !ok {
+		return nil
+	}
+
+	var req WebhookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	if req.URL != "" {
+		webhook.URL = req.URL
+	}
+	if req.Events != nil {
+		webhook.Events = req.Events
+	}
+	if msg := validateWebhook(webhook.URL, webhook.Events); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": msg})
+	}
+	if req.Active != nil {
+		webhook.Active = *req.Active
+	}
+	if req.Secret != "" {
+		webhook.Secret = req.Secret
+	}
+
+	if err := config.DB.Omit("Project").Save(&webhook).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update webhook"})
+	}
+
+	response := models.FormatWebhookResponse(webhook)
+	if req.Secret != "" {
+		response.Secret = webhook.Secret
+	}
+
+	return c.JSON(response)
+}
+
+// DeleteWebhook removes a webhook with its deliveries
+func DeleteWebhook(c *fiber.Ctx) error {
+	webhook, ok := getOwnedWebhook(c)
+	if !ok {
+		return nil
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id = ?", webhook.ID).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&webhook).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete webhook"})
+	}
+
+	return c.Status(fiber.StatusOK).SendString("Webhook deleted")
+}
+
+// GetWebhookDeliveries is the delivery log of a webhook, newest first. status= filters on
+// pending, succeeded or failed.
+func GetWebhookDeliveries(c *fiber.Ctx) error {
+	webhook, ok := getOwnedWebhook(c)
+	if !ok {
+		return nil
+	}
+
+	query := config.DB.Model(&models.WebhookDelivery{}).Where("webhook_id = ?", webhook.ID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if !isCursorMode(c) {
+		query = query.Order("created_at DESC, id DESC")
+	}
+
+	return paginate(c, query, "webhook_deliveries.created_at", "webhook_deliveries.id", deliveryCursor, formatDelivery)
+}
+
+// RedeliverWebhook queues the payload of a past delivery again, as a new delivery
+func RedeliverWebhook(c *fiber.Ctx) error {
+	webhook, ok := getOwnedWebhook(c)
+	if !ok {
+		return nil
+	}
+
+	var delivery models.WebhookDelivery
+	if err := config.DB.First(&delivery, "id = ? AND webhook_id = ?", c.Params("deliveryId"), webhook.ID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Delivery not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve delivery"})
+	}
+
+	redelivery := jobs.NewRedelivery(delivery)
+	if err := config.DB.Create(&redelivery).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to queue delivery"})
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(models.FormatWebhookDeliveryResponse(redelivery))
+}
+
+// getOwnedWebhook loads the webhook from the :id param for its owner or an admin.
+// When ok is false the error response has already been written.
+func getOwnedWebhook(c *fiber.Ctx) (webhook models.Webhook, ok bool) {
+	if err := config.DB.Preload("Project").First(&webhook, "id = ?", c.Params("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Webhook not found"})
+			return webhook, false
+		}
+		c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve webhook"})
+		return webhook, false
+	}
+
+	user := GetUserByID(c)
+	if !utils.HasPermission(GetRole(c), utils.WebhookManageAny, webhook.UserID, user.ID) {
+		c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Webhook not found"})
+		return webhook, false
+	}
+
+	return webhook, true
+}
+
+// validateWebhook returns what is wrong with the URL or events, or an empty string
+func validateWebhook(rawURL string, events []string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "URL must be an absolute http or https URL"
+	}
+	if err := jobs.CheckWebhookHost(u.Hostname()); err != nil {
+		return "URL host must resolve to a public address"
+	}
+	if len(events) == 0 {
+		return "Events must list at least one event"
+	}
+	for _, event := range events {
+		if event != models.EventAll && !slices.Contains(models.Events, event) {
+			return "Unknown event " + event + ", expected one of " + strings.Join(models.Events, ", ") + " or *"
+		}
+	}
+	return ""
+}
+
+// emitWebhooks queues the event for the active webhooks that subscribe to it and can see tasks
+// of the project (or tasks without a project when projectID is nil)
+func emitWebhooks(db *gorm.DB, event string, projectID *string, data interface{}) error {
+	query := db.Where("active")
+	if projectID != nil {
+		query = query.Where(
+			"project_id = ? OR (project_id IS NULL AND (user_id IN (?) OR user_id IN (?)))", *projectID,
+			db.Model(&models.ProjectMember{}).Select("user_id").Where("project_id = ?", *projectID),
+			db.Model(&models.User{}).Select("id").Where("role = ?", utils.AdminRole),
+		)
+	} else {
+		query = query.Where("project_id IS NULL")
+	}
+
+	var webhooks []models.Webhook
+	if err := query.Find(&webhooks).Error; err != nil {
+		return err
+	}
+	webhooks = slices.DeleteFunc(webhooks, func(w models.Webhook) bool {
+		return !slices.Contains(w.Events, event) && !slices.Contains(w.Events, models.EventAll)
+	})
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	payload := models.WebhookPayload{ID: uuid.NewString(), Event: event, CreatedAt: time.Now(), Data: data}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	var deliveries []models.WebhookDelivery
+	for _, webhook := range webhooks {
+		deliveries = append(deliveries, models.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			EventID:       payload.ID,
+			Event:         event,
+			Payload:       string(body),
+			Status:        models.DeliveryPending,
+			NextAttemptAt: payload.CreatedAt,
+		})
+	}
+	return db.Create(&deliveries).Error
+}
+
+// queueWebhooks is the webhooks subscriber, it turns domain events into webhook events
+func queueWebhooks(tx *gorm.DB, event models.OutboxEvent) error {
+	switch event.Type {
+	case models.TaskCreated, models.TaskUpdated, models.TaskDeleted:
+		var payload models.TaskEvent
+		if err := event.Decode(&payload); err != nil {
+			return err
+		}
+		webhookEvent := map[string]string{
+			models.TaskCreated: models.EventTaskCreated,
+			models.TaskUpdated: models.EventTaskUpdated,
+			models.TaskDeleted: models.EventTaskDeleted,
+		}[event.Type]
+		return emitWebhooks(tx, webhookEvent, payload.Task.ProjectID, payload.Task)
+	}
+
+	// The other events are about a task that still exists, unless it was deleted in the meantime
+	var task models.Task
+	if err := tx.First(&task, "id = ?", event.AggregateID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return err
+	}
+
+	switch event.Type {
+	case models.CommentAdded:
+		var payload models.CommentEvent
+		if err := event.Decode(&payload); err != nil {
+			return err
+		}
+		var comment models.Comment
+		if err := tx.Preload("User").Preload("Mentions.User").First(&comment, "id = ?", payload.CommentID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil
+			}
+			return err
+		}
+		return emitWebhooks(tx, models.EventCommentCreated, task.ProjectID, models.FormatCommentResponse(comment))
+	case models.HistoryRecorded:
+		var payload models.HistoryEvent
+		if err := event.Decode(&payload); err != nil {
+			return err
+		}
+		var history models.History
+		if err := tx.First(&history, "id = ?", payload.HistoryID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil
+			}
+			return err
+		}
+		response, err := models.FormatHistoryResponse(history)
+		if err != nil {
+			return err
+		}
+		return emitWebhooks(tx, models.EventHistoryCreated, task.ProjectID, response)
+	}
+	return nil
+}
+
+func deliveryCursor(delivery models.WebhookDelivery) utils.Cursor {
+	return utils.Cursor{Time: delivery.CreatedAt, ID: delivery.ID}
+}
+
+func formatDelivery(delivery models.WebhookDelivery) interface{} {
+	return models.FormatWebhookDeliveryResponse(delivery)
+}
@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"task-management-api/config"
+	"task-management-api/models"
+	"task-management-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type WorkflowRequest struct {
+	Name         string `json:"name"`
+	InitialState string `json:"initial_state"`
+	States       []struct {
+		Name     string `json:"name"`
+		Label    string `json:"label"`
+		Category string `json:"category"`
+	} `json:"states"`
+	Transitions []struct {
+		From         string `json:"from"`
+		To           string `json:"to"`
+		RequiredRole string `json:"required_role"`
+	} `json:"transitions"`
+}
+
+var stateNamePattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]{0,19}$`)
+
+func GetDefaultWorkflow(c *fiber.Ctx) error {
+	workflow, err := getWorkflow(nil)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve workflow"})
+	}
+
+	return c.JSON(workflow)
+}
+
+func UpdateDefaultWorkflow(c *fiber.Ctx) error {
+	return saveWorkflow(c, nil)
+}
+
+// GetProjectWorkflow returns the workflow a board renders its columns from
+func GetProjectWorkflow(c *fiber.Ctx) error {
+	project, ok := getProjectForMember(c)
+	if !ok {
+		return nil
+	}
+
+	workflow, err := getWorkflow(&project.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve workflow"})
+	}
+
+	return c.JSON(workflow)
+}
+
+func UpdateProjectWorkflow(c *fiber.Ctx) error {
+	project, ok := getProjectForOwner(c)
+	if !ok {
+		return nil
+	}
+
+	return saveWorkflow(c, &project.ID)
+}
+
+// saveWorkflow replaces the states and transitions of the project workflow,
+// or of the default workflow when projectID is nil
+func saveWorkflow(c *fiber.Ctx, projectID *string) error {
+	var req WorkflowRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	workflow, err := buildWorkflow(req)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	workflow.ProjectID = projectID
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		// Tasks must not be left in a state that no longer exists
+		var orphaned int64
+		if err := workflowTasks(tx, projectID).Where("status NOT IN ?", workflow.StateNames()).Count(&orphaned).Error; err != nil {
+			return err
+		}
+		if orphaned > 0 {
+			return fiber.NewError(fiber.StatusConflict, fmt.Sprintf("%d tasks are in states missing from the workflow", orphaned))
+		}
+
+		var existing models.Workflow
+		query := tx.Where("project_id IS NULL")
+		if projectID != nil {
+			query = tx.Where("project_id = ?", *projectID)
+		}
+		err := query.First(&existing).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return err
+		}
+
+		if err == nil {
+			if err := tx.Where("workflow_id = ?", existing.ID).Delete(&models.WorkflowState{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("workflow_id = ?", existing.ID).Delete(&models.WorkflowTransition{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Delete(&existing).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Create(&workflow).Error
+	})
+	if err != nil {
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) {
+			return c.Status(fiberErr.Code).JSON(fiber.Map{"error": fiberErr.Message})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update workflow"})
+	}
+
+	return c.JSON(workflow)
+}
+
+// getWorkflow loads the workflow of the project, falling back to the default workflow
+func getWorkflow(projectID *string) (models.Workflow, error) {
+	var workflow models.Workflow
+
+	query := config.DB.
+		Preload("States", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+		Preload("Transitions").
+		Session(&gorm.Session{})
+
+	if projectID != nil {
+		err := query.Where("project_id = ?", *projectID).First(&workflow).Error
+		if err != gorm.ErrRecordNotFound {
+			return workflow, err
+		}
+	}
+
+	err := query.Where("project_id IS NULL").First(&workflow).Error
+	return workflow, err
+}
+
+// workflowTasks selects the tasks governed by the project workflow or the default workflow
+func workflowTasks(tx *gorm.DB, projectID *string) *gorm.DB {
+	query := tx.Model(&models.Task{})
+	if projectID != nil {
+		return query.Where("project_id = ?", *projectID)
+	}
+	customized := tx.Model(&models.Workflow{}).Select("project_id").Where("project_id IS NOT NULL")
+	return query.Where("project_id IS NULL OR project_id NOT IN (?)", customized)
+}
+
+func buildWorkflow(req WorkflowRequest) (models.Workflow, error) {
+	workflow := models.Workflow{
+		Name:         req.Name,
+		InitialState: req.InitialState,
+	}
+	if workflow.Name == "" {
+		workflow.Name = "Custom"
+	}
+	if len(req.States) == 0 {
+		return workflow, errors.New("Workflow must have at least one state")
+	}
+
+	for i, s := range req.States {
+		if !stateNamePattern.MatchString(s.Name) {
+			return workflow, fmt.Errorf("State %q must be 1-20 uppercase letters, digits or underscores", s.Name)
+		}
+		if _, exists := workflow.State(s.Name); exists {
+			return workflow, fmt.Errorf("State %q is defined twice", s.Name)
+		}
+		if s.Category != models.CategoryTodo && s.Category != models.CategoryInProgress && s.Category != models.CategoryDone {
+			return workflow, fmt.Errorf("State %q category must be one of: todo, in_progress, done", s.Name)
+		}
+		label := s.Label
+		if label == "" {
+			label = s.Name
+		}
+		workflow.States = append(workflow.States, models.WorkflowState{Name: s.Name, Label: label, Category: s.Category, Position: i})
+	}
+
+	if workflow.InitialState == "" {
+		workflow.InitialState = workflow.States[0].Name
+	}
+	if _, ok := workflow.State(workflow.InitialState); !ok {
+		return workflow, fmt.Errorf("Initial state %q is not a workflow state", workflow.InitialState)
+	}
+
+	for _, t := range req.Transitions {
+		_, fromOk := workflow.State(t.From)
+		_, toOk := workflow.State(t.To)
+		if !fromOk || !toOk {
+			return workflow, fmt.Errorf("Transition %s -> %s uses an unknown state", t.From, t.To)
+		}
+		if t.RequiredRole != "" && !utils.ValidateRole(t.RequiredRole) {
+			return workflow, fmt.Errorf("Transition %s -> %s requires an unknown role %q", t.From, t.To, t.RequiredRole)
+		}
+		workflow.Transitions = append(workflow.Transitions, models.WorkflowTransition{FromState: t.From, ToState: t.To, RequiredRole: t.RequiredRole})
+	}
+
+	return workflow, nil
+}
+
+// return true if status is one of the workflow states
+func validateStatus(workflow models.Workflow, status string) bool {
+	_, ok := workflow.State(status)
+	return ok
+}
+
+// checkStatusChange validates moving the task to status against its workflow, and refuses to
+// finish a task with open blockers or open subtasks. It returns the HTTP status to respond with.
+func checkStatusChange(task models.Task, status utils.Status, role string) (int, error) {
+	workflow, err := getWorkflow(task.ProjectID)
+	if err != nil {
+		return fiber.StatusInternalServerError, errors.New("Failed to retrieve workflow")
+	}
+	if code, err := checkTransition(workflow, string(task.Status), string(status), role); err != nil {
+		return code, err
+	}
+
+	if state, _ := workflow.State(string(status)); state.Category == models.CategoryDone && status != task.Status {
+		open, err := incompleteDependencies(task.ID)
+		if err != nil {
+			return fiber.StatusInternalServerError, errors.New("Failed to retrieve blockers")
+		}
+		if len(open) > 0 {
+			return fiber.StatusConflict, errors.New("Task has open blockers or subtasks: " + strings.Join(open, ", "))
+		}
+	}
+
+	return 0, nil
+}
+
+// checkTransition validates a status change against the workflow and the user's role.
+// It returns the HTTP status to respond with when the change is not allowed.
+func checkTransition(workflow models.Workflow, from string, to string, role string) (int, error) {
+	if !validateStatus(workflow, to) {
+		return fiber.StatusBadRequest, fmt.Errorf("Status must be one of: %s", strings.Join(workflow.StateNames(), ", "))
+	}
+	if from == to {
+		return 0, nil
+	}
+
+	transition, ok := workflow.Transition(from, to)
+	if !ok {
+		return fiber.StatusBadRequest, fmt.Errorf("Cannot move task from %s to %s", from, to)
+	}
+	if transition.RequiredRole != "" && role != transition.RequiredRole && !utils.RoleHasPermission(role, utils.WorkflowTransitionAny) {
+		return fiber.StatusForbidden, fmt.Errorf("Moving task from %s to %s requires the %s role", from, to, transition.RequiredRole)
+	}
+
+	return 0, nil
+}
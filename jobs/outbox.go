@@ -0,0 +1,28 @@
+package jobs
+
+import (
+	"log"
+	"os"
+	"task-management-api/events"
+	"time"
+)
+
+// StartOutboxJob hands the outbox events that were not dispatched right after their change,
+// or whose subscribers failed, to the subscribers, every OUTBOX_JOB_INTERVAL (default 5s)
+func StartOutboxJob() {
+	interval, err := time.ParseDuration(os.Getenv("OUTBOX_JOB_INTERVAL"))
+	if err != nil || interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for ; true; <-ticker.C {
+			if err := events.DispatchDue(); err != nil {
+				log.Println("Failed to dispatch events:", err)
+			}
+		}
+	}()
+}
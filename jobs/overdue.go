@@ -0,0 +1,51 @@
+package jobs
+
+import (
+	"log"
+	"os"
+	"task-management-api/config"
+	"time"
+)
+
+// A task is overdue when its due date has passed and it is not in a done state
+// of its workflow (the project workflow, or the default one)
+const overdueCondition = `(
+	tasks.due_date IS NOT NULL AND tasks.due_date < now() AND NOT EXISTS (
+		SELECT 1 FROM workflow_states ws JOIN workflows w ON w.id = ws.workflow_id
+		WHERE ws.name = tasks.status AND ws.category = 'done' AND (
+			w.project_id = tasks.project_id OR (
+				w.project_id IS NULL AND NOT EXISTS (
+					SELECT 1 FROM workflows pw WHERE pw.project_id = tasks.project_id
+				)
+			)
+		)
+	)
+)`
+
+// RefreshOverdue recomputes the overdue flag of the given tasks, or of all tasks when none are given
+func RefreshOverdue(taskIDs ...string) error {
+	query := "UPDATE tasks SET overdue = " + overdueCondition + " WHERE overdue IS DISTINCT FROM " + overdueCondition
+	if len(taskIDs) > 0 {
+		return config.DB.Exec(query+" AND tasks.id IN ?", taskIDs).Error
+	}
+	return config.DB.Exec(query).Error
+}
+
+// StartOverdueJob flags overdue tasks in the background, every OVERDUE_JOB_INTERVAL (default 5m)
+func StartOverdueJob() {
+	interval, err := time.ParseDuration(os.Getenv("OVERDUE_JOB_INTERVAL"))
+	if err != nil || interval <= 0 {
+		interval = 5 * time.Minute
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for ; true; <-ticker.C {
+			if err := RefreshOverdue(); err != nil {
+				log.Println("Failed to flag overdue tasks:", err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,255 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"strconv"
+	"syscall"
+	"task-management-api/config"
+	"task-management-api/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	webhookBatchSize   = 20
+	webhookBaseBackoff = 30 * time.Second
+	webhookMaxBackoff  = time.Hour
+)
+
+// WebhookClient sends the deliveries. Redirects are not followed, they count as failures, and
+// connections are only made to the addresses WebhookAddressAllowed accepts, checked after DNS resolution.
+var WebhookClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		DialContext:         (&net.Dialer{Timeout: 5 * time.Second, Control: webhookDialControl}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+var ErrWebhookAddress = errors.New("webhooks cannot be sent to loopback, private or link-local addresses")
+
+// Special purpose IPv4 ranges that IsGlobalUnicast and IsPrivate let through
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+}
+
+// WebhookAddressAllowed reports whether webhooks can be sent to the address. Loopback, private, link-local
+// (which includes the 169.254.169.254 metadata service) and other reserved addresses are refused,
+// unless WEBHOOK_ALLOW_PRIVATE_HOSTS is true, e.g. for a receiver running next to the API in development.
+func WebhookAddressAllowed(addr netip.Addr) bool {
+	if allow, _ := strconv.ParseBool(os.Getenv("WEBHOOK_ALLOW_PRIVATE_HOSTS")); allow {
+		return true
+	}
+
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckWebhookHost resolves the host of a webhook URL and checks every address it resolves to
+func CheckWebhookHost(host string) error {
+	addrs, err := net.DefaultResolver.LookupNetIP(context.Background(), "ip", host)
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if !WebhookAddressAllowed(addr) {
+			return ErrWebhookAddress
+		}
+	}
+	return nil
+}
+
+// webhookDialControl refuses to connect to addresses that are not allowed, once the host is resolved
+func webhookDialControl(network string, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !WebhookAddressAllowed(addrPort.Addr()) {
+		return ErrWebhookAddress
+	}
+	return nil
+}
+
+// webhookMaxAttempts is how many times a delivery is tried before it is marked failed,
+// WEBHOOK_MAX_ATTEMPTS (default 8)
+func webhookMaxAttempts() int {
+	if attempts, err := strconv.Atoi(os.Getenv("WEBHOOK_MAX_ATTEMPTS")); err == nil && attempts > 0 {
+		return attempts
+	}
+	return 8
+}
+
+// WebhookBackoff is the wait before the next try after the given number of attempts,
+// doubling from 30s up to an hour
+func WebhookBackoff(attempts int) time.Duration {
+	backoff := webhookBaseBackoff
+	for i := 1; i < attempts && backoff < webhookMaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, webhookMaxBackoff)
+}
+
+// SignWebhookPayload is the X-Webhook-Signature of a payload: sha256= and the hex HMAC-SHA256 of the body
+func SignWebhookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// DeliverWebhooks sends up to a batch of the deliveries that are due, one at a time
+func DeliverWebhooks() error {
+	for range webhookBatchSize {
+		delivery, ok, err := claimWebhookDelivery()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+		deliverWebhook(delivery)
+	}
+	return nil
+}
+
+// claimWebhookDelivery takes the next due delivery right before it is sent. Its next attempt is pushed past
+// the request timeout, so concurrent workers skip it, and it is released when the result is saved.
+func claimWebhookDelivery() (delivery models.WebhookDelivery, ok bool, err error) {
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		var due []models.WebhookDelivery
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, time.Now()).
+			Order("next_attempt_at").Limit(1).Find(&due).Error; err != nil {
+			return err
+		}
+		if len(due) == 0 {
+			return nil
+		}
+
+		delivery, ok = due[0], true
+		return tx.Model(&models.WebhookDelivery{}).Where("id = ?", delivery.ID).Update("next_attempt_at", time.Now().Add(2*WebhookClient.Timeout)).Error
+	})
+	return delivery, ok, err
+}
+
+// NewRedelivery queues the event of a delivery again, as a new delivery of the same payload due right away
+func NewRedelivery(delivery models.WebhookDelivery) models.WebhookDelivery {
+	return models.WebhookDelivery{
+		WebhookID:     delivery.WebhookID,
+		EventID:       delivery.EventID,
+		Event:         delivery.Event,
+		Payload:       delivery.Payload,
+		Status:        models.DeliveryPending,
+		NextAttemptAt: time.Now(),
+	}
+}
+
+// deliverWebhook posts one delivery and records the outcome
+func deliverWebhook(delivery models.WebhookDelivery) {
+	var webhook models.Webhook
+	if err := config.DB.First(&webhook, "id = ?", delivery.WebhookID).Error; err != nil {
+		log.Println("Failed to load webhook:", err)
+		return
+	}
+
+	delivery.Attempts++
+	status, err := postWebhook(webhook, delivery)
+	if err := config.DB.Model(&delivery).Updates(deliveryOutcome(webhook, delivery, status, err, time.Now())).Error; err != nil {
+		log.Println("Failed to save webhook delivery:", err)
+	}
+}
+
+// deliveryOutcome is what to save on a delivery after an attempt that got the response status and err.
+// Failures are retried with backoff until the last attempt, or right away for an inactive webhook.
+func deliveryOutcome(webhook models.Webhook, delivery models.WebhookDelivery, status *int, err error, now time.Time) map[string]interface{} {
+	updates := map[string]interface{}{"attempts": delivery.Attempts, "response_status": status, "error": ""}
+	switch {
+	case err == nil:
+		updates["status"] = models.DeliverySucceeded
+		updates["delivered_at"] = now
+	case delivery.Attempts >= webhookMaxAttempts() || !webhook.Active:
+		updates["status"] = models.DeliveryFailed
+		updates["error"] = err.Error()
+	default:
+		updates["next_attempt_at"] = now.Add(WebhookBackoff(delivery.Attempts))
+		updates["error"] = err.Error()
+	}
+	return updates
+}
+
+// postWebhook sends the signed payload and returns the response status, any non 2xx status is an error
+func postWebhook(webhook models.Webhook, delivery models.WebhookDelivery) (*int, error) {
+	if !webhook.Active {
+		return nil, errors.New("webhook is inactive")
+	}
+
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "task-management-api-webhooks")
+	req.Header.Set("X-Webhook-Event", delivery.Event)
+	req.Header.Set("X-Webhook-Id", delivery.EventID)
+	req.Header.Set("X-Webhook-Delivery", delivery.ID)
+	req.Header.Set("X-Webhook-Signature", SignWebhookPayload(webhook.Secret, body))
+
+	resp, err := WebhookClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &resp.StatusCode, errors.New("receiver responded " + resp.Status)
+	}
+	return &resp.StatusCode, nil
+}
+
+// StartWebhookJob sends due webhook deliveries in the background, every WEBHOOK_JOB_INTERVAL (default 5s)
+func StartWebhookJob() {
+	interval, err := time.ParseDuration(os.Getenv("WEBHOOK_JOB_INTERVAL"))
+	if err != nil || interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for ; true; <-ticker.C {
+			if err := DeliverWebhooks(); err != nil {
+				log.Println("Failed to deliver webhooks:", err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,253 @@
+package jobs
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"task-management-api/models"
+	"testing"
+	"time"
+)
+
+// receiver records the requests of a webhook delivery test and answers them with status
+type receiver struct {
+	status int
+	bodies []string
+	heads  []http.Header
+}
+
+func newReceiver(t *testing.T, status int) (*receiver, *httptest.Server) {
+	t.Helper()
+	t.Setenv("WEBHOOK_ALLOW_PRIVATE_HOSTS", "true") // httptest listens on loopback
+
+	r := &receiver{status: status}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.bodies = append(r.bodies, string(body))
+		r.heads = append(r.heads, req.Header.Clone())
+		w.WriteHeader(r.status)
+	}))
+	t.Cleanup(server.Close)
+	return r, server
+}
+
+func testDelivery() models.WebhookDelivery {
+	return models.WebhookDelivery{
+		ID:        "6f1c1b2e-6d1e-4c39-9a57-3a4f9f0f2d11",
+		WebhookID: "0a8c3c5e-1f5b-4a51-8d0f-2b8c1e7e4b22",
+		EventID:   "b3d4e5f6-7a8b-4c9d-8e0f-1a2b3c4d5e33",
+		Event:     models.EventTaskCreated,
+		Payload:   `{"id":"b3d4e5f6-7a8b-4c9d-8e0f-1a2b3c4d5e33","event":"task.created","data":{"title":"Ship it"}}`,
+		Status:    models.DeliveryPending,
+	}
+}
+
+func TestSignWebhookPayload(t *testing.T) {
+	// printf '{}' | openssl dgst -sha256 -hmac secret
+	const want = "sha256=77325902caca812dc259733aacd046b73817372c777b8d95b402647474516e13"
+	if got := SignWebhookPayload("secret", []byte("{}")); got != want {
+		t.Fatalf("SignWebhookPayload = %q, want %q", got, want)
+	}
+	if SignWebhookPayload("other", []byte("{}")) == want || SignWebhookPayload("secret", []byte("{ }")) == want {
+		t.Fatal("signature does not depend on the secret and the body")
+	}
+}
+
+func TestPostWebhookSignsPayload(t *testing.T) {
+	r, server := newReceiver(t, http.StatusNoContent)
+	webhook := models.Webhook{URL: server.URL, Secret: "s3cret", Active: true}
+	delivery := testDelivery()
+
+	status, err := postWebhook(webhook, delivery)
+	if err != nil {
+		t.Fatalf("postWebhook: %v", err)
+	}
+	if status == nil || *status != http.StatusNoContent {
+		t.Fatalf("status = %v, want 204", status)
+	}
+
+	if len(r.bodies) != 1 || r.bodies[0] != delivery.Payload {
+		t.Fatalf("receiver got %q, want the payload", r.bodies)
+	}
+	head := r.heads[0]
+	if got, want := head.Get("X-Webhook-Signature"), SignWebhookPayload(webhook.Secret, []byte(delivery.Payload)); got != want {
+		t.Errorf("X-Webhook-Signature = %q, want %q", got, want)
+	}
+	for name, want := range map[string]string{
+		"Content-Type":       "application/json",
+		"X-Webhook-Event":    delivery.Event,
+		"X-Webhook-Id":       delivery.EventID,
+		"X-Webhook-Delivery": delivery.ID,
+	} {
+		if got := head.Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestNon2xxIsRetriedWithBackoff(t *testing.T) {
+	t.Setenv("WEBHOOK_MAX_ATTEMPTS", "3")
+	_, server := newReceiver(t, http.StatusInternalServerError)
+	webhook := models.Webhook{URL: server.URL, Secret: "s3cret", Active: true}
+	now := time.Now()
+
+	for attempts := 1; attempts < 3; attempts++ {
+		delivery := testDelivery()
+		delivery.Attempts = attempts
+
+		status, err := postWebhook(webhook, delivery)
+		if err == nil {
+			t.Fatal("a 500 response should fail the attempt")
+		}
+		if status == nil || *status != http.StatusInternalServerError {
+			t.Fatalf("status = %v, want 500", status)
+		}
+
+		updates := deliveryOutcome(webhook, delivery, status, err, now)
+		if _, ok := updates["status"]; ok {
+			t.Fatalf("attempt %d: status changed to %v, want it left pending", attempts, updates["status"])
+		}
+		if got, want := updates["next_attempt_at"], now.Add(WebhookBackoff(attempts)); got != want {
+			t.Errorf("attempt %d: next_attempt_at = %v, want %v", attempts, got, want)
+		}
+		if updates["error"] == "" || updates["attempts"] != attempts {
+			t.Errorf("attempt %d: updates = %v", attempts, updates)
+		}
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	for _, tt := range []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{7, 32 * time.Minute},
+		{8, time.Hour},
+		{50, time.Hour},
+	} {
+		if got := WebhookBackoff(tt.attempts); got != tt.want {
+			t.Errorf("WebhookBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestDeliveryFailsAfterMaxAttempts(t *testing.T) {
+	t.Setenv("WEBHOOK_MAX_ATTEMPTS", "3")
+	_, server := newReceiver(t, http.StatusBadGateway)
+	webhook := models.Webhook{URL: server.URL, Secret: "s3cret", Active: true}
+	delivery := testDelivery()
+	delivery.Attempts = 3
+
+	status, err := postWebhook(webhook, delivery)
+	updates := deliveryOutcome(webhook, delivery, status, err, time.Now())
+	if updates["status"] != models.DeliveryFailed {
+		t.Fatalf("status = %v, want %s", updates["status"], models.DeliveryFailed)
+	}
+	if _, ok := updates["next_attempt_at"]; ok {
+		t.Error("a failed delivery should not be scheduled again")
+	}
+
+	// an inactive webhook fails right away, without a request
+	webhook.Active = false
+	delivery.Attempts = 1
+	status, err = postWebhook(webhook, delivery)
+	if updates := deliveryOutcome(webhook, delivery, status, err, time.Now()); updates["status"] != models.DeliveryFailed {
+		t.Fatalf("inactive webhook: status = %v, want %s", updates["status"], models.DeliveryFailed)
+	}
+}
+
+func TestDeliverySucceeds(t *testing.T) {
+	_, server := newReceiver(t, http.StatusOK)
+	webhook := models.Webhook{URL: server.URL, Secret: "s3cret", Active: true}
+	delivery := testDelivery()
+	delivery.Attempts = 1
+	now := time.Now()
+
+	status, err := postWebhook(webhook, delivery)
+	updates := deliveryOutcome(webhook, delivery, status, err, now)
+	if updates["status"] != models.DeliverySucceeded || updates["delivered_at"] != now || updates["error"] != "" {
+		t.Fatalf("updates = %v, want a succeeded delivery", updates)
+	}
+}
+
+func TestRedeliver(t *testing.T) {
+	r, server := newReceiver(t, http.StatusOK)
+	webhook := models.Webhook{URL: server.URL, Secret: "s3cret", Active: true}
+	failed := testDelivery()
+	failed.Status = models.DeliveryFailed
+	failed.Attempts = 8
+	failed.Error = "receiver responded 500 Internal Server Error"
+
+	redelivery := NewRedelivery(failed)
+	if redelivery.ID != "" || redelivery.Status != models.DeliveryPending || redelivery.Attempts != 0 || redelivery.Error != "" {
+		t.Fatalf("redelivery = %+v, want a new pending delivery", redelivery)
+	}
+	if time.Until(redelivery.NextAttemptAt) > 0 {
+		t.Error("redelivery should be due right away")
+	}
+	if redelivery.WebhookID != failed.WebhookID || redelivery.EventID != failed.EventID || redelivery.Event != failed.Event {
+		t.Errorf("redelivery = %+v, want the webhook and event of the original", redelivery)
+	}
+
+	redelivery.ID = "9e8d7c6b-5a49-4382-9172-6f5e4d3c2b44"
+	redelivery.Attempts++
+	status, err := postWebhook(webhook, redelivery)
+	if updates := deliveryOutcome(webhook, redelivery, status, err, time.Now()); updates["status"] != models.DeliverySucceeded {
+		t.Fatalf("redelivery: %v", err)
+	}
+	if r.bodies[0] != failed.Payload {
+		t.Errorf("redelivered body = %q, want the original payload", r.bodies[0])
+	}
+	if got, want := r.heads[0].Get("X-Webhook-Signature"), SignWebhookPayload(webhook.Secret, []byte(failed.Payload)); got != want {
+		t.Errorf("X-Webhook-Signature = %q, want %q", got, want)
+	}
+	if r.heads[0].Get("X-Webhook-Id") != failed.EventID || r.heads[0].Get("X-Webhook-Delivery") != redelivery.ID {
+		t.Errorf("headers = %v, want the event id of the original and the new delivery id", r.heads[0])
+	}
+}
+
+func TestWebhookAddressAllowed(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"93.184.216.34":       true,
+		"2606:4700::1111":     true,
+		"127.0.0.1":           false,
+		"::1":                 false,
+		"10.1.2.3":            false,
+		"172.16.0.1":          false,
+		"192.168.1.1":         false,
+		"169.254.169.254":     false,
+		"fe80::1":             false,
+		"fd00:ec2::254":       false,
+		"100.64.0.1":          false,
+		"0.0.0.0":             false,
+		"::ffff:127.0.0.1":    false,
+		"::ffff:93.184.216.3": true,
+	} {
+		if got := WebhookAddressAllowed(netip.MustParseAddr(addr)); got != want {
+			t.Errorf("WebhookAddressAllowed(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func TestPrivateHostsAreRefused(t *testing.T) {
+	_, server := newReceiver(t, http.StatusOK)
+	t.Setenv("WEBHOOK_ALLOW_PRIVATE_HOSTS", "")
+
+	if err := CheckWebhookHost("127.0.0.1"); !errors.Is(err, ErrWebhookAddress) {
+		t.Errorf("CheckWebhookHost(127.0.0.1) = %v, want %v", err, ErrWebhookAddress)
+	}
+	if err := CheckWebhookHost("localhost"); err == nil {
+		t.Error("CheckWebhookHost(localhost) should fail")
+	}
+
+	// the dialer checks the resolved address, whatever the URL says
+	webhook := models.Webhook{URL: server.URL, Secret: "s3cret", Active: true}
+	if _, err := postWebhook(webhook, testDelivery()); !errors.Is(err, ErrWebhookAddress) {
+		t.Fatalf("postWebhook to %s = %v, want %v", server.URL, err, ErrWebhookAddress)
+	}
+}
@@ -4,7 +4,10 @@ import (
 	"log"
 	"os"
 	"task-management-api/config"
+	"task-management-api/handlers"
+	"task-management-api/jobs"
 	"task-management-api/routes"
+	"task-management-api/storage"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/logger"
@@ -14,8 +17,14 @@ import (
 func main() {
 	godotenv.Load()
 	config.ConnectDB()
+	handlers.SubscribeEvents()
+	jobs.StartOverdueJob()
+	jobs.StartOutboxJob()
+	jobs.StartWebhookJob()
+	storage.Init()
 
-	app := fiber.New()
+	// Leave room for the multipart overhead around the largest allowed attachment
+	app := fiber.New(fiber.Config{BodyLimit: int(storage.MaxSize) + 1<<20})
 	app.Use(logger.New())
 
 	api := app.Group("/api")
@@ -26,7 +35,20 @@ func main() {
 	})
 	routes.AuthRoutes(v1)
 	routes.TaskRoutes(v1)
+	routes.BoardRoutes(v1)
 	routes.CommentRoutes(v1)
+	routes.UserRoutes(v1)
+	routes.ProjectRoutes(v1)
+	routes.SprintRoutes(v1)
+	routes.WorkflowRoutes(v1)
+	routes.SearchRoutes(v1)
+	routes.FilterRoutes(v1)
+	routes.LabelRoutes(v1)
+	routes.NotificationRoutes(v1)
+	routes.AttachmentRoutes(v1)
+	routes.WebhookRoutes(v1)
+	routes.StreamRoutes(v1)
+	routes.EventRoutes(v1)
 
 	port := os.Getenv("PORT")
 	log.Fatal(app.Listen(":" + port))
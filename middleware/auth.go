@@ -2,9 +2,12 @@ package middleware
 
 import (
 	"strings"
+	"task-management-api/config"
+	"task-management-api/models"
 	"task-management-api/utils"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
 )
 
 func AuthMiddleware(c *fiber.Ctx) error {
@@ -25,8 +28,47 @@ func AuthMiddleware(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
 	}
 
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+	userID, _ := claims["user_id"].(string)
+
+	// Reject access tokens revoked by logout
+	jti, _ := claims["jti"].(string)
+	var revoked int64
+	if err := config.DB.Model(&models.RevokedToken{}).Where("jti = ?", jti).Count(&revoked).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to verify token"})
+	}
+	if jti == "" || revoked > 0 {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Token has been revoked"})
+	}
+
+	// Load the current role so role changes apply without a new token
+	var user models.User
+	if err := config.DB.Select("id", "role").First(&user, "id = ?", userID).Error; err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "User not found"})
+	}
+
 	// Attach user info to the request context
-	c.Locals("user", token.Claims)
+	c.Locals("user", claims)
+	c.Locals("role", user.Role)
 
 	return c.Next()
 }
+
+// OptionalAuthMiddleware authenticates the request only when an Authorization header is sent
+func OptionalAuthMiddleware(c *fiber.Ctx) error {
+	if c.Get("Authorization") == "" {
+		return c.Next()
+	}
+	return AuthMiddleware(c)
+}
+
+// QueryTokenAuthMiddleware also accepts the access token as ?access_token=, for clients such as EventSource that cannot set headers
+func QueryTokenAuthMiddleware(c *fiber.Ctx) error {
+	if token := c.Query("access_token"); token != "" && c.Get("Authorization") == "" {
+		c.Request().Header.Set("Authorization", "Bearer "+token)
+	}
+	return AuthMiddleware(c)
+}
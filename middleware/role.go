@@ -1,13 +1,28 @@
 package middleware
 
-import "github.com/gofiber/fiber/v2"
+import (
+	"task-management-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
 
 func RoleMiddleware(requiredRole string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		role := c.Locals("role").(string)
+		role, _ := c.Locals("role").(string)
 		if role != requiredRole {
 			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Access denied"})
 		}
 		return c.Next()
 	}
 }
+
+// PermissionMiddleware only lets through users whose role grants the permission
+func PermissionMiddleware(permission utils.Permission) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role, _ := c.Locals("role").(string)
+		if !utils.RoleHasPermission(role, permission) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Access denied"})
+		}
+		return c.Next()
+	}
+}
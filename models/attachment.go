@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// Attachment is a file uploaded to a task, or to one of its comments.
+// The contents live in the storage backend under StorageKey.
+type Attachment struct {
+	ID          string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TaskID      string    `gorm:"type:uuid;not null;index" json:"task_id"`
+	CommentID   *string   `gorm:"type:uuid;default:NULL;index" json:"comment_id"`
+	FileName    string    `gorm:"not null" json:"file_name"`
+	ContentType string    `gorm:"not null" json:"content_type"`
+	Size        int64     `gorm:"not null" json:"size"`
+	StorageKey  string    `gorm:"not null;unique" json:"-"`
+	CreatedBy   string    `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+
+	// Relationships
+	User User `gorm:"foreignKey:CreatedBy"`
+}
+
+type AttachmentResponse struct {
+	ID          string    `json:"id"`
+	TaskID      string    `json:"task_id"`
+	CommentID   *string   `json:"comment_id,omitempty"`
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	DownloadURL string    `json:"download_url"`
+	CreatedBy   string    `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func FormatAttachmentResponse(attachment Attachment) AttachmentResponse {
+	createdBy := attachment.CreatedBy
+	if attachment.User.Email != "" {
+		createdBy = attachment.User.Email
+	}
+	return AttachmentResponse{
+		ID:          attachment.ID,
+		TaskID:      attachment.TaskID,
+		CommentID:   attachment.CommentID,
+		FileName:    attachment.FileName,
+		ContentType: attachment.ContentType,
+		Size:        attachment.Size,
+		DownloadURL: "/api/v1/attachments/" + attachment.ID + "/download",
+		CreatedBy:   createdBy,
+		CreatedAt:   attachment.CreatedAt,
+	}
+}
@@ -0,0 +1,14 @@
+package models
+
+// BoardColumn is a workflow state with its tasks in rank order
+type BoardColumn struct {
+	Status   string         `json:"status"`
+	Label    string         `json:"label"`
+	Category string         `json:"category,omitempty"` // empty for statuses no longer in the workflow
+	Tasks    []TaskResponse `json:"tasks"`
+}
+
+type BoardResponse struct {
+	ProjectID *string       `json:"project_id,omitempty"`
+	Columns   []BoardColumn `json:"columns"`
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"slices"
 	"time"
 )
 
@@ -8,22 +9,30 @@ type Comment struct {
 	ID        string    `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
 	Content   string    `gorm:"not null"`
 	TaskID    string    `gorm:"type:uuid;not null" json:"task_id"`
+	ParentID  *string   `gorm:"type:uuid;index;default:NULL" json:"parent_id"` // the comment replied to
 	CreatedBy string    `gorm:"type:uuid" json:"created_by"`
 	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
 	UpdatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
 
 	// Relationships
-	User User `gorm:"foreignKey:CreatedBy"`
-	Task Task `gorm:"foreignKey:TaskID"`
+	User      User              `gorm:"foreignKey:CreatedBy"`
+	Task      Task              `gorm:"foreignKey:TaskID"`
+	Mentions  []CommentMention  `gorm:"foreignKey:CommentID"`
+	Reactions []CommentReaction `gorm:"foreignKey:CommentID"`
 }
 
 type CommentResponse struct {
-	ID        string    `json:"id"`
-	Content   string    `json:"content"`
-	TaskID    string    `json:"task_id"`
-	CreatedBy string    `json:"created_by"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID          string             `json:"id"`
+	Content     string             `json:"content"`
+	ContentHTML string             `json:"content_html,omitempty"` // with ?render=html
+	TaskID      string             `json:"task_id"`
+	ParentID    *string            `json:"parent_id,omitempty"`
+	CreatedBy   string             `json:"created_by"`
+	Mentions    []UserResponse     `json:"mentions"`
+	Reactions   []ReactionResponse `json:"reactions"`
+	Replies     []CommentResponse  `json:"replies,omitempty"` // in task details
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
 }
 
 func FormatCommentResponse(comment Comment) CommentResponse {
@@ -31,12 +40,71 @@ func FormatCommentResponse(comment Comment) CommentResponse {
 	if comment.User.Email != "" {
 		createdBy = comment.User.Email
 	}
+	mentions := []UserResponse{}
+	for _, mention := range comment.Mentions {
+		mentions = append(mentions, FormatUserResponse(mention.User))
+	}
 	return CommentResponse{
 		ID:        comment.ID,
 		Content:   comment.Content,
 		TaskID:    comment.TaskID,
+		ParentID:  comment.ParentID,
 		CreatedBy: createdBy,
+		Mentions:  mentions,
+		Reactions: FormatReactionsResponse(comment.Reactions),
 		CreatedAt: comment.CreatedAt,
 		UpdatedAt: comment.UpdatedAt,
 	}
 }
+
+// CommentMention is a user mentioned with @ in a comment
+type CommentMention struct {
+	CommentID string    `gorm:"type:uuid;primaryKey" json:"comment_id"`
+	UserID    string    `gorm:"type:uuid;primaryKey;index" json:"user_id"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+
+	// Relationships
+	User User `gorm:"foreignKey:UserID"`
+}
+
+// CommentReaction is an emoji a user reacted to a comment with
+type CommentReaction struct {
+	CommentID string    `gorm:"type:uuid;primaryKey" json:"comment_id"`
+	UserID    string    `gorm:"type:uuid;primaryKey" json:"user_id"`
+	Emoji     string    `gorm:"type:varchar(32);primaryKey" json:"emoji"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+
+	// Relationships
+	User User `gorm:"foreignKey:UserID"`
+}
+
+// ReactionResponse counts the users who reacted to a comment with an emoji
+type ReactionResponse struct {
+	Emoji string   `json:"emoji"`
+	Count int      `json:"count"`
+	Users []string `json:"users"`
+}
+
+// FormatReactionsResponse groups reactions by emoji, in the order each emoji was first used
+func FormatReactionsResponse(reactions []CommentReaction) []ReactionResponse {
+	sorted := slices.Clone(reactions)
+	slices.SortStableFunc(sorted, func(a, b CommentReaction) int { return a.CreatedAt.Compare(b.CreatedAt) })
+
+	response := []ReactionResponse{}
+	index := map[string]int{}
+	for _, reaction := range sorted {
+		i, ok := index[reaction.Emoji]
+		if !ok {
+			i = len(response)
+			index[reaction.Emoji] = i
+			response = append(response, ReactionResponse{Emoji: reaction.Emoji, Users: []string{}})
+		}
+		user := reaction.UserID
+		if reaction.User.Email != "" {
+			user = reaction.User.Email
+		}
+		response[i].Count++
+		response[i].Users = append(response[i].Users, user)
+	}
+	return response
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+const (
+	FieldText        = "text"
+	FieldNumber      = "number"
+	FieldDate        = "date"
+	FieldSelect      = "select"
+	FieldMultiSelect = "multi_select"
+	FieldUser        = "user"
+)
+
+// CustomField defines an extra task field of a project, values live in Task.CustomFields under Key
+type CustomField struct {
+	ID        string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ProjectID string    `gorm:"type:uuid;not null;uniqueIndex:idx_custom_field_key" json:"project_id"`
+	Key       string    `gorm:"type:varchar(40);not null;uniqueIndex:idx_custom_field_key" json:"key"` // e.g. story_points
+	Name      string    `gorm:"not null" json:"name"`
+	Type      string    `gorm:"type:varchar(20);not null" json:"type"`
+	Options   []string  `gorm:"type:jsonb;serializer:json" json:"options,omitempty"` // choices of select fields
+	Required  bool      `gorm:"not null;default:false" json:"required"`
+	Position  int       `gorm:"not null;default:0" json:"position"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Domain events, published to the outbox in the transaction of the change they describe
+const (
+	TaskCreated     = "TaskCreated"
+	TaskUpdated     = "TaskUpdated"
+	TaskDeleted     = "TaskDeleted"
+	TaskAssigned    = "TaskAssigned"
+	CommentAdded    = "CommentAdded"
+	CommentEdited   = "CommentEdited"
+	HistoryRecorded = "HistoryRecorded"
+)
+
+// OutboxEvent is a published domain event. Each subscriber handles it once, the event is
+// processed when all of them have, and retried with backoff until then. An event that keeps
+// failing is marked failed, so the later events of its task go on, until it is retried by hand.
+type OutboxEvent struct {
+	ID            int64      `gorm:"primaryKey;autoIncrement" json:"id"` // publication order
+	Type          string     `gorm:"type:varchar(50);not null" json:"type"`
+	AggregateID   string     `gorm:"type:uuid;not null;index" json:"aggregate_id"` // the task
+	ProjectID     *string    `gorm:"type:uuid;default:NULL" json:"project_id"`     // of the task, to scope who sees the event
+	ActorID       string     `gorm:"type:uuid" json:"actor_id"`
+	Payload       string     `gorm:"type:jsonb;not null" json:"payload"`
+	Handled       []string   `gorm:"type:jsonb;serializer:json;not null;default:'[]'" json:"handled"` // subscribers done with it
+	Attempts      int        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP" json:"next_attempt_at"`
+	LastError     string     `json:"last_error"`
+	ProcessedAt   *time.Time `gorm:"default:NULL;index" json:"processed_at"`
+	FailedAt      *time.Time `gorm:"default:NULL;index" json:"failed_at"` // when it ran out of attempts
+	CreatedAt     time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+type OutboxEventResponse struct {
+	ID          int64           `json:"id"`
+	Type        string          `json:"type"`
+	AggregateID string          `json:"aggregate_id"`
+	ActorID     string          `json:"actor_id"`
+	Payload     json.RawMessage `json:"payload"`
+	Handled     []string        `json:"handled"`
+	Attempts    int             `json:"attempts"`
+	LastError   string          `json:"last_error"`
+	FailedAt    *time.Time      `json:"failed_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+func FormatOutboxEventResponse(event OutboxEvent) OutboxEventResponse {
+	return OutboxEventResponse{
+		ID:          event.ID,
+		Type:        event.Type,
+		AggregateID: event.AggregateID,
+		ActorID:     event.ActorID,
+		Payload:     json.RawMessage(event.Payload),
+		Handled:     event.Handled,
+		Attempts:    event.Attempts,
+		LastError:   event.LastError,
+		FailedAt:    event.FailedAt,
+		CreatedAt:   event.CreatedAt,
+	}
+}
+
+// Decode reads the payload into v
+func (e OutboxEvent) Decode(v interface{}) error {
+	return json.Unmarshal([]byte(e.Payload), v)
+}
+
+// TaskEvent is the payload of TaskCreated, TaskUpdated and TaskDeleted, with the task as it is after the change
+type TaskEvent struct {
+	Task    TaskResponse                 `json:"task"`
+	Changes map[string]map[string]string `json:"changes,omitempty"` // TaskUpdated, field: {from, to}
+}
+
+// TaskAssignedEvent lists the users newly assigned to the task
+type TaskAssignedEvent struct {
+	UserIDs []string `json:"user_ids"`
+}
+
+// CommentEvent is the payload of CommentAdded and CommentEdited
+type CommentEvent struct {
+	CommentID string   `json:"comment_id"`
+	Mentioned []string `json:"mentioned"` // users newly mentioned
+}
+
+type HistoryEvent struct {
+	HistoryID string `json:"history_id"`
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// TaskFilter holds the task listing query parameters, either from a request or saved
+type TaskFilter struct {
+	Project   string `json:"project,omitempty"`
+	Title     string `json:"title,omitempty"`
+	Status    string `json:"status,omitempty"`
+	Assignee  string `json:"assignee,omitempty"`
+	CreatedBy string `json:"createdBy,omitempty"`
+	Overdue   string `json:"overdue,omitempty"`
+	DueBefore string `json:"due_before,omitempty"`
+	DueAfter  string `json:"due_after,omitempty"`
+	Label     string `json:"label,omitempty"`     // comma separated, tasks must have all labels
+	LabelAny  string `json:"label_any,omitempty"` // comma separated, tasks must have one of the labels
+	Watching  string `json:"watching,omitempty"`  // user ID or me
+	Where     string `json:"where,omitempty"`
+	Sort      string `json:"sort,omitempty"`
+}
+
+// Merge returns the filter with empty fields taken from base
+func (f TaskFilter) Merge(base TaskFilter) TaskFilter {
+	pick := func(value string, fallback string) string {
+		if value != "" {
+			return value
+		}
+		return fallback
+	}
+
+	return TaskFilter{
+		Project:   pick(f.Project, base.Project),
+		Title:     pick(f.Title, base.Title),
+		Status:    pick(f.Status, base.Status),
+		Assignee:  pick(f.Assignee, base.Assignee),
+		CreatedBy: pick(f.CreatedBy, base.CreatedBy),
+		Overdue:   pick(f.Overdue, base.Overdue),
+		DueBefore: pick(f.DueBefore, base.DueBefore),
+		DueAfter:  pick(f.DueAfter, base.DueAfter),
+		Label:     pick(f.Label, base.Label),
+		LabelAny:  pick(f.LabelAny, base.LabelAny),
+		Watching:  pick(f.Watching, base.Watching),
+		Where:     pick(f.Where, base.Where),
+		Sort:      pick(f.Sort, base.Sort),
+	}
+}
+
+type SavedFilter struct {
+	ID        string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Name      string    `gorm:"not null" json:"name"`
+	OwnerID   string    `gorm:"type:uuid;not null;index" json:"owner_id"`
+	ProjectID *string   `gorm:"type:uuid;index;default:NULL" json:"project_id"` // shared with the project members when set
+	Criteria  string    `gorm:"type:jsonb;not null" json:"criteria"`            // Store as JSON
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+
+	// Relationships
+	Owner   User    `gorm:"foreignKey:OwnerID"`
+	Project Project `gorm:"foreignKey:ProjectID"`
+}
+
+type SavedFilterResponse struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Owner     string     `json:"owner"`
+	ProjectID *string    `json:"project_id,omitempty"`
+	Criteria  TaskFilter `json:"criteria"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+func FormatSavedFilterResponse(filter SavedFilter) (SavedFilterResponse, error) {
+	var criteria TaskFilter
+
+	// Unmarshal JSON string into structured TaskFilter
+	if err := json.Unmarshal([]byte(filter.Criteria), &criteria); err != nil {
+		return SavedFilterResponse{}, err
+	}
+
+	owner := filter.OwnerID
+	if filter.Owner.Email != "" {
+		owner = filter.Owner.Email
+	}
+
+	return SavedFilterResponse{
+		ID:        filter.ID,
+		Name:      filter.Name,
+		Owner:     owner,
+		ProjectID: filter.ProjectID,
+		Criteria:  criteria,
+		CreatedAt: filter.CreatedAt,
+		UpdatedAt: filter.UpdatedAt,
+	}, nil
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+type Label struct {
+	ID        string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ProjectID *string   `gorm:"type:uuid;index;default:NULL" json:"project_id"` // NULL for labels usable in every project
+	Name      string    `gorm:"not null" json:"name"`
+	Color     string    `gorm:"type:varchar(7);not null;default:'#888888'" json:"color"`
+	CreatedBy string    `gorm:"type:uuid" json:"created_by"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+type LabelResponse struct {
+	ID        string  `json:"id"`
+	ProjectID *string `json:"project_id,omitempty"`
+	Name      string  `json:"name"`
+	Color     string  `json:"color"`
+}
+
+func FormatLabelResponse(label Label) LabelResponse {
+	return LabelResponse{
+		ID:        label.ID,
+		ProjectID: label.ProjectID,
+		Name:      label.Name,
+		Color:     label.Color,
+	}
+}
+
+func FormatLabelsResponse(labels []Label) []LabelResponse {
+	response := []LabelResponse{}
+	for _, label := range labels {
+		response = append(response, FormatLabelResponse(label))
+	}
+	return response
+}
@@ -0,0 +1,66 @@
+package models
+
+import "time"
+
+// Link types as stored, "is blocked by" is stored as the inverse "blocks" link
+const (
+	LinkBlocks      = "blocks"
+	LinkIsBlockedBy = "is_blocked_by"
+	LinkRelatesTo   = "relates_to"
+	LinkDuplicates  = "duplicates"
+)
+
+type TaskLink struct {
+	ID        string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	SourceID  string    `gorm:"type:uuid;not null;uniqueIndex:idx_task_link" json:"source_id"`
+	TargetID  string    `gorm:"type:uuid;not null;uniqueIndex:idx_task_link;index" json:"target_id"`
+	Type      string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_task_link" json:"type"`
+	CreatedBy string    `gorm:"type:uuid" json:"created_by"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+
+	// Relationships
+	Source Task `gorm:"foreignKey:SourceID" json:"-"`
+	Target Task `gorm:"foreignKey:TargetID" json:"-"`
+}
+
+type LinkedTaskResponse struct {
+	ID     string  `json:"id"`
+	Key    *string `json:"key,omitempty"`
+	Title  string  `json:"title"`
+	Status string  `json:"status"`
+}
+
+type TaskLinkResponse struct {
+	ID        string             `json:"id"`
+	Type      string             `json:"type"`
+	Task      LinkedTaskResponse `json:"task"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+func FormatLinkedTaskResponse(task Task) LinkedTaskResponse {
+	return LinkedTaskResponse{
+		ID:     task.ID,
+		Key:    task.Key,
+		Title:  task.Title,
+		Status: string(task.Status),
+	}
+}
+
+// FormatTaskLinkResponse describes the link from the point of view of the given task
+func FormatTaskLinkResponse(link TaskLink, taskID string) TaskLinkResponse {
+	linkType := link.Type
+	other := link.Target
+	if link.TargetID == taskID {
+		other = link.Source
+		if link.Type == LinkBlocks {
+			linkType = LinkIsBlockedBy
+		}
+	}
+
+	return TaskLinkResponse{
+		ID:        link.ID,
+		Type:      linkType,
+		Task:      FormatLinkedTaskResponse(other),
+		CreatedAt: link.CreatedAt,
+	}
+}
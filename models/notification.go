@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+const (
+	NotificationAssigned  = "assigned"
+	NotificationCommented = "commented"
+	NotificationMentioned = "mentioned"
+)
+
+// Notification is an inbox entry telling a user about something that happened on a task
+type Notification struct {
+	ID        string     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID    string     `gorm:"type:uuid;not null;index" json:"user_id"` // recipient
+	ActorID   string     `gorm:"type:uuid;not null" json:"actor_id"`
+	Type      string     `gorm:"type:varchar(20);not null" json:"type"`
+	TaskID    string     `gorm:"type:uuid;not null;index" json:"task_id"`
+	CommentID *string    `gorm:"type:uuid;default:NULL" json:"comment_id"`
+	Message   string     `gorm:"not null" json:"message"`
+	ReadAt    *time.Time `gorm:"default:NULL" json:"read_at"`
+	CreatedAt time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+
+	// Relationships
+	Actor User `gorm:"foreignKey:ActorID"`
+	Task  Task `gorm:"foreignKey:TaskID"`
+}
+
+type NotificationResponse struct {
+	ID        string     `json:"id"`
+	Type      string     `json:"type"`
+	Message   string     `json:"message"`
+	TaskID    string     `json:"task_id"`
+	TaskKey   *string    `json:"task_key,omitempty"`
+	CommentID *string    `json:"comment_id,omitempty"`
+	Actor     string     `json:"actor"`
+	Read      bool       `json:"read"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func FormatNotificationResponse(notification Notification) NotificationResponse {
+	actor := notification.ActorID
+	if notification.Actor.Email != "" {
+		actor = notification.Actor.Email
+	}
+	return NotificationResponse{
+		ID:        notification.ID,
+		Type:      notification.Type,
+		Message:   notification.Message,
+		TaskID:    notification.TaskID,
+		TaskKey:   notification.Task.Key,
+		CommentID: notification.CommentID,
+		Actor:     actor,
+		Read:      notification.ReadAt != nil,
+		ReadAt:    notification.ReadAt,
+		CreatedAt: notification.CreatedAt,
+	}
+}
@@ -17,3 +17,20 @@ func TransformPagination(pagination *Pagination) map[string]interface{} {
 		"data":       pagination.Data,
 	}
 }
+
+// CursorPagination is the keyset alternative to Pagination, the cursors are opaque tokens
+type CursorPagination struct {
+	PageSize   int    `json:"pageSize"`
+	NextCursor string `json:"next_cursor"`
+	PrevCursor string `json:"prev_cursor"`
+	Data       []interface{}
+}
+
+func TransformCursorPagination(pagination *CursorPagination) map[string]interface{} {
+	return map[string]interface{}{
+		"pageSize":    pagination.PageSize,
+		"next_cursor": pagination.NextCursor,
+		"prev_cursor": pagination.PrevCursor,
+		"data":        pagination.Data,
+	}
+}
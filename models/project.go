@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+type Project struct {
+	ID          string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Name        string    `gorm:"not null" json:"name"`
+	Key         string    `gorm:"type:varchar(10);uniqueIndex;not null" json:"key"`
+	Description string    `json:"description"`
+	OwnerID     string    `gorm:"type:uuid;not null" json:"owner_id"`
+	TaskCounter int       `gorm:"not null;default:0" json:"-"` // last issued task number
+	CreatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+
+	// Relationships
+	Owner User `gorm:"foreignKey:OwnerID"`
+}
+
+type ProjectMember struct {
+	ProjectID string    `gorm:"type:uuid;primaryKey" json:"project_id"`
+	UserID    string    `gorm:"type:uuid;primaryKey" json:"user_id"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+
+	// Relationships
+	Project Project `gorm:"foreignKey:ProjectID"`
+	User    User    `gorm:"foreignKey:UserID"`
+}
+
+type ProjectResponse struct {
+	ID          string         `json:"id"`
+	Name        string         `json:"name"`
+	Key         string         `json:"key"`
+	Description string         `json:"description"`
+	Owner       string         `json:"owner"`
+	Members     []UserResponse `json:"members,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+func FormatProjectResponse(project Project, members []ProjectMember) ProjectResponse {
+	owner := project.OwnerID
+	if project.Owner.Email != "" {
+		owner = project.Owner.Email
+	}
+
+	var membersResponse []UserResponse
+	for _, member := range members {
+		membersResponse = append(membersResponse, FormatUserResponse(member.User))
+	}
+
+	return ProjectResponse{
+		ID:          project.ID,
+		Name:        project.Name,
+		Key:         project.Key,
+		Description: project.Description,
+		Owner:       owner,
+		Members:     membersResponse,
+		CreatedAt:   project.CreatedAt,
+		UpdatedAt:   project.UpdatedAt,
+	}
+}
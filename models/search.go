@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+const (
+	SearchTypeTask    = "task"
+	SearchTypeComment = "comment"
+)
+
+// SearchResult is a ranked full-text hit on a task or a comment
+type SearchResult struct {
+	Type      string    `json:"type"`
+	ID        string    `json:"id"`
+	TaskID    string    `json:"task_id"`
+	TaskKey   *string   `json:"task_key,omitempty"`
+	Title     string    `json:"title"`
+	Highlight string    `json:"highlight"` // matches wrapped in <mark></mark>
+	Rank      float64   `json:"rank"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,115 @@
+package models
+
+import "time"
+
+// Sprint states, a project has at most one active sprint
+const (
+	SprintPlanned   = "PLANNED"
+	SprintActive    = "ACTIVE"
+	SprintCompleted = "COMPLETED"
+)
+
+// What became of a task of a sprint
+const (
+	SprintTaskCompleted   = "COMPLETED"
+	SprintTaskCarriedOver = "CARRIED_OVER"
+	SprintTaskRemoved     = "REMOVED"
+)
+
+type Sprint struct {
+	ID            string     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ProjectID     string     `gorm:"type:uuid;not null;index" json:"project_id"`
+	Name          string     `gorm:"not null" json:"name"`
+	Goal          string     `json:"goal"`
+	State         string     `gorm:"type:varchar(20);not null;default:'PLANNED'" json:"state"`
+	StartDate     *time.Time `gorm:"default:NULL" json:"start_date"`
+	EndDate       *time.Time `gorm:"default:NULL" json:"end_date"`
+	CompletedAt   *time.Time `gorm:"default:NULL" json:"completed_at"`
+	CarriedOverTo *string    `gorm:"type:uuid;default:NULL" json:"carried_over_to"` // the sprint unfinished tasks moved to, NULL for the backlog
+	CreatedBy     string     `gorm:"type:uuid" json:"created_by"`
+	CreatedAt     time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+
+	// Relationships
+	Project Project `gorm:"foreignKey:ProjectID" json:"-"`
+}
+
+// SprintTask records a task's stay in a sprint, for the sprint report
+type SprintTask struct {
+	SprintID        string    `gorm:"type:uuid;primaryKey" json:"sprint_id"`
+	TaskID          string    `gorm:"type:uuid;primaryKey;index" json:"task_id"`
+	AddedAt         time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"added_at"`
+	AddedAfterStart bool      `gorm:"not null;default:false" json:"added_after_start"`     // scope added to an active sprint
+	Outcome         string    `gorm:"type:varchar(20);not null;default:''" json:"outcome"` // empty while the task is in the sprint
+	Status          string    `gorm:"type:varchar(20)" json:"status"`                      // of the task when the sprint completed
+	Estimate        *int      `gorm:"default:NULL" json:"estimate"`                        // of the task when the sprint completed
+
+	// Relationships
+	Task Task `gorm:"foreignKey:TaskID"`
+}
+
+type SprintResponse struct {
+	ID            string         `json:"id"`
+	ProjectID     string         `json:"project_id"`
+	Name          string         `json:"name"`
+	Goal          string         `json:"goal"`
+	State         string         `json:"state"`
+	StartDate     *time.Time     `json:"start_date,omitempty"`
+	EndDate       *time.Time     `json:"end_date,omitempty"`
+	CompletedAt   *time.Time     `json:"completed_at,omitempty"`
+	CarriedOverTo *string        `json:"carried_over_to,omitempty"`
+	CreatedBy     string         `json:"created_by"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	Tasks         []TaskResponse `json:"tasks,omitempty"` // in sprint details
+}
+
+func FormatSprintResponse(sprint Sprint) SprintResponse {
+	return SprintResponse{
+		ID:            sprint.ID,
+		ProjectID:     sprint.ProjectID,
+		Name:          sprint.Name,
+		Goal:          sprint.Goal,
+		State:         sprint.State,
+		StartDate:     sprint.StartDate,
+		EndDate:       sprint.EndDate,
+		CompletedAt:   sprint.CompletedAt,
+		CarriedOverTo: sprint.CarriedOverTo,
+		CreatedBy:     sprint.CreatedBy,
+		CreatedAt:     sprint.CreatedAt,
+		UpdatedAt:     sprint.UpdatedAt,
+	}
+}
+
+// SprintReport sums up a sprint, live while it is active
+type SprintReport struct {
+	Sprint          SprintResponse    `json:"sprint"`
+	Completed       SprintReportGroup `json:"completed"`
+	CarriedOver     SprintReportGroup `json:"carried_over"` // not done, carried over when the sprint completes
+	Removed         SprintReportGroup `json:"removed"`      // taken out while the sprint was active
+	AddedAfterStart int               `json:"added_after_start"`
+}
+
+type SprintReportGroup struct {
+	Count    int                `json:"count"`
+	Estimate int                `json:"estimate"` // in minutes
+	Tasks    []SprintReportTask `json:"tasks"`
+}
+
+type SprintReportTask struct {
+	ID              string  `json:"id"`
+	Key             *string `json:"key,omitempty"`
+	Title           string  `json:"title"`
+	Status          string  `json:"status"`
+	Estimate        *int    `json:"estimate,omitempty"`
+	AddedAfterStart bool    `json:"added_after_start"`
+}
+
+// Add counts the task in the group
+func (g *SprintReportGroup) Add(task SprintReportTask) {
+	g.Count++
+	if task.Estimate != nil {
+		g.Estimate += *task.Estimate
+	}
+	g.Tasks = append(g.Tasks, task)
+}
@@ -5,60 +5,117 @@ import (
 	"time"
 )
 
-type TaskStatus string
-
-const (
-	Todo       TaskStatus = "TODO"
-	InProgress TaskStatus = "IN_PROGRESS"
-	InReview   TaskStatus = "IN_REVIEW"
-	Done       TaskStatus = "DONE"
-)
-
 type Task struct {
-	ID          string       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	Title       string       `json:"title"`
-	Description string       `json:"description"`
-	Status      utils.Status `gorm:"type:varchar(20);default:'TODO'" json:"status"`
-	Assignee    *string      `gorm:"type:uuid;default:NULL" json:"assignee"`
-	CreatedBy   string       `gorm:"type:uuid" json:"created_by"`
-	UpdatedBy   string       `gorm:"type:uuid" json:"updated_by"`
-	CreatedAt   time.Time    `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
-	UpdatedAt   time.Time    `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+	ID               string                 `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ProjectID        *string                `gorm:"type:uuid;index;default:NULL" json:"project_id"`
+	Key              *string                `gorm:"type:varchar(20);uniqueIndex;default:NULL" json:"key"` // e.g. WEB-123, set for project tasks
+	ParentID         *string                `gorm:"type:uuid;index;default:NULL" json:"parent_id"`
+	SprintID         *string                `gorm:"type:uuid;index;default:NULL" json:"sprint_id"` // NULL for the backlog
+	Type             utils.TaskType         `gorm:"type:varchar(20);not null;default:'TASK'" json:"type"`
+	Title            string                 `json:"title"`
+	Description      string                 `json:"description"`
+	StepsToReproduce string                 `json:"steps_to_reproduce"` // for bugs
+	Status           utils.Status           `gorm:"type:varchar(20);default:'TODO'" json:"status"`
+	Rank             string                 `gorm:"type:varchar(64);not null;default:''" json:"rank"` // position in the board column, see utils.RankBetween
+	Priority         utils.Priority         `gorm:"type:varchar(20);not null;default:'MEDIUM'" json:"priority"`
+	Severity         *utils.Severity        `gorm:"type:varchar(20);default:NULL" json:"severity"` // for bugs
+	Assignee         *string                `gorm:"type:uuid;default:NULL" json:"assignee"`
+	StartDate        *time.Time             `gorm:"default:NULL" json:"start_date"`
+	DueDate          *time.Time             `gorm:"index;default:NULL" json:"due_date"`
+	Estimate         *int                   `gorm:"default:NULL" json:"estimate"`                                          // in minutes
+	Overdue          bool                   `gorm:"not null;default:false" json:"overdue"`                                 // flagged by the overdue job
+	LabelIDs         []string               `gorm:"-" json:"label_ids,omitempty"`                                          // sets the labels on create and update
+	AssigneeIDs      []string               `gorm:"-" json:"assignee_ids,omitempty"`                                       // sets all assignees, the first one becoming the primary assignee
+	CustomFields     map[string]interface{} `gorm:"type:jsonb;serializer:json;not null;default:'{}'" json:"custom_fields"` // values of the project's custom fields by key
+	CreatedBy        string                 `gorm:"type:uuid" json:"created_by"`
+	UpdatedBy        string                 `gorm:"type:uuid" json:"updated_by"`
+	CreatedAt        time.Time              `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt        time.Time              `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
 
 	// Relationships
-	CreatedUser  User `gorm:"foreignKey:CreatedBy" json:"created_user"`
-	UpdatedUser  User `gorm:"foreignKey:UpdatedBy" json:"updated_user"`
-	AssigneeUser User `gorm:"foreignKey:Assignee" json:"assignee_user"`
+	CreatedUser  User           `gorm:"foreignKey:CreatedBy" json:"created_user"`
+	UpdatedUser  User           `gorm:"foreignKey:UpdatedBy" json:"updated_user"`
+	AssigneeUser User           `gorm:"foreignKey:Assignee" json:"assignee_user"`
+	Project      Project        `gorm:"foreignKey:ProjectID" json:"-"`
+	Labels       []Label        `gorm:"many2many:task_labels" json:"-"`
+	CoAssignees  []TaskAssignee `gorm:"foreignKey:TaskID" json:"-"`
+	Watchers     []TaskWatcher  `gorm:"foreignKey:TaskID" json:"-"`
 }
 
 type TaskResponse struct {
-	ID          string    `json:"id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Status      string    `json:"status"`
-	Assignee    *string   `json:"assignee,omitempty"`
-	CreatedBy   string    `json:"created_by"`
-	UpdatedBy   string    `json:"updated_by"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID               string                 `json:"id"`
+	ProjectID        *string                `json:"project_id,omitempty"`
+	Key              *string                `json:"key,omitempty"`
+	ParentID         *string                `json:"parent_id,omitempty"`
+	SprintID         *string                `json:"sprint_id,omitempty"`
+	Type             string                 `json:"type"`
+	Title            string                 `json:"title"`
+	Description      string                 `json:"description"`
+	DescriptionHTML  string                 `json:"description_html,omitempty"` // with ?render=html
+	StepsToReproduce string                 `json:"steps_to_reproduce,omitempty"`
+	Status           string                 `json:"status"`
+	Rank             string                 `json:"rank,omitempty"`
+	Priority         string                 `json:"priority"`
+	Severity         *utils.Severity        `json:"severity,omitempty"`
+	Assignee         *string                `json:"assignee,omitempty"`
+	Assignees        []string               `json:"assignees,omitempty"` // primary assignee first
+	StartDate        *time.Time             `json:"start_date,omitempty"`
+	DueDate          *time.Time             `json:"due_date,omitempty"`
+	Estimate         *int                   `json:"estimate,omitempty"`
+	Overdue          bool                   `json:"overdue"`
+	Labels           []LabelResponse        `json:"labels"`
+	CustomFields     map[string]interface{} `json:"custom_fields,omitempty"`
+	CreatedBy        string                 `json:"created_by"`
+	UpdatedBy        string                 `json:"updated_by"`
+	CreatedAt        time.Time              `json:"created_at"`
+	UpdatedAt        time.Time              `json:"updated_at"`
 }
 
 type TaskDetailsResponse struct {
-	ID          string            `json:"id"`
-	Title       string            `json:"title"`
-	Description string            `json:"description"`
-	Status      string            `json:"status"`
-	Assignee    *string           `json:"assignee,omitempty"`
-	CreatedBy   string            `json:"created_by"`
-	UpdatedBy   string            `json:"updated_by"`
-	CreatedAt   time.Time         `json:"created_at"`
-	UpdatedAt   time.Time         `json:"updated_at"`
-	Comments    []CommentResponse `json:"comments"`
-	History     []HistoryResponse `json:"history"`
+	ID               string                 `json:"id"`
+	ProjectID        *string                `json:"project_id,omitempty"`
+	Key              *string                `json:"key,omitempty"`
+	ParentID         *string                `json:"parent_id,omitempty"`
+	Type             string                 `json:"type"`
+	Title            string                 `json:"title"`
+	Description      string                 `json:"description"`
+	DescriptionHTML  string                 `json:"description_html,omitempty"` // with ?render=html
+	StepsToReproduce string                 `json:"steps_to_reproduce,omitempty"`
+	Status           string                 `json:"status"`
+	Priority         string                 `json:"priority"`
+	Severity         *utils.Severity        `json:"severity,omitempty"`
+	Assignee         *string                `json:"assignee,omitempty"`
+	Assignees        []string               `json:"assignees,omitempty"` // primary assignee first
+	StartDate        *time.Time             `json:"start_date,omitempty"`
+	DueDate          *time.Time             `json:"due_date,omitempty"`
+	Estimate         *int                   `json:"estimate,omitempty"`
+	Overdue          bool                   `json:"overdue"`
+	Labels           []LabelResponse        `json:"labels"`
+	CustomFields     map[string]interface{} `json:"custom_fields,omitempty"`
+	CreatedBy        string                 `json:"created_by"`
+	UpdatedBy        string                 `json:"updated_by"`
+	CreatedAt        time.Time              `json:"created_at"`
+	UpdatedAt        time.Time              `json:"updated_at"`
+	Progress         *TaskProgress          `json:"progress,omitempty"` // for epics
+	Watchers         []UserResponse         `json:"watchers"`
+	Subtasks         []LinkedTaskResponse   `json:"subtasks"`
+	Links            []TaskLinkResponse     `json:"links"`
+	Comments         []CommentResponse      `json:"comments"`
+	Attachments      []AttachmentResponse   `json:"attachments"`
+	History          []HistoryResponse      `json:"history"`
+}
+
+// TaskProgress rolls up the completion of an epic's children
+type TaskProgress struct {
+	Total   int `json:"total"`
+	Done    int `json:"done"`
+	Percent int `json:"percent"`
 }
 
 // Function to convert Task model to response format
 func FormatTaskResponse(task Task) TaskResponse {
+	assignees := TaskAssigneeIDs(task)
+
 	// using user id instead of email for task creation
 	createdBy := task.CreatedBy
 	updatedBy := task.UpdatedBy
@@ -74,14 +131,42 @@ func FormatTaskResponse(task Task) TaskResponse {
 	}
 
 	return TaskResponse{
-		ID:          task.ID,
-		Title:       task.Title,
-		Description: task.Description,
-		Status:      string(task.Status),
-		Assignee:    assignee,
-		CreatedBy:   createdBy,
-		UpdatedBy:   updatedBy,
-		CreatedAt:   task.CreatedAt,
-		UpdatedAt:   task.UpdatedAt,
+		ID:               task.ID,
+		ProjectID:        task.ProjectID,
+		Key:              task.Key,
+		ParentID:         task.ParentID,
+		SprintID:         task.SprintID,
+		Type:             string(task.Type),
+		Title:            task.Title,
+		Description:      task.Description,
+		StepsToReproduce: task.StepsToReproduce,
+		Status:           string(task.Status),
+		Priority:         string(task.Priority),
+		Severity:         task.Severity,
+		Assignee:         assignee,
+		Assignees:        assignees,
+		StartDate:        task.StartDate,
+		DueDate:          task.DueDate,
+		Estimate:         task.Estimate,
+		Overdue:          task.Overdue,
+		Rank:             task.Rank,
+		Labels:           FormatLabelsResponse(task.Labels),
+		CustomFields:     task.CustomFields,
+		CreatedBy:        createdBy,
+		UpdatedBy:        updatedBy,
+		CreatedAt:        task.CreatedAt,
+		UpdatedAt:        task.UpdatedAt,
+	}
+}
+
+// TaskAssigneeIDs lists the primary assignee followed by the other assignees
+func TaskAssigneeIDs(task Task) []string {
+	var ids []string
+	if task.Assignee != nil {
+		ids = append(ids, *task.Assignee)
+	}
+	for _, assignee := range task.CoAssignees {
+		ids = append(ids, assignee.UserID)
 	}
+	return ids
 }
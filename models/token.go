@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+type RefreshToken struct {
+	ID        string     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID    string     `gorm:"type:uuid;not null;index" json:"user_id"`
+	TokenHash string     `gorm:"uniqueIndex;not null" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+
+	// Relationships
+	User User `gorm:"foreignKey:UserID"`
+}
+
+// RevokedToken blocks an access token by its jti until it expires
+type RevokedToken struct {
+	JTI       string    `gorm:"primaryKey" json:"jti"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+}
@@ -1,6 +1,10 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"task-management-api/utils"
+
+	"gorm.io/gorm"
+)
 
 type User struct {
 	// Adds created_at & updated_at automatically
@@ -11,3 +15,21 @@ type User struct {
 	Password string `gorm:"not null" json:"-"`
 	Role     string `gorm:"not null;default:'user'" json:"role"`
 }
+
+type UserResponse struct {
+	ID    string `json:"id"`
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+func FormatUserResponse(user User) UserResponse {
+	role := user.Role
+	if role == "" {
+		role = string(utils.UserRole)
+	}
+	return UserResponse{
+		ID:    user.ID,
+		Email: user.Email,
+		Role:  role,
+	}
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// TaskWatcher is a user following a task without owning it
+type TaskWatcher struct {
+	TaskID    string    `gorm:"type:uuid;primaryKey" json:"task_id"`
+	UserID    string    `gorm:"type:uuid;primaryKey;index" json:"user_id"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+
+	// Relationships
+	User User `gorm:"foreignKey:UserID"`
+}
+
+// TaskAssignee is an assignee of a task besides the primary Task.Assignee
+type TaskAssignee struct {
+	TaskID    string    `gorm:"type:uuid;primaryKey" json:"task_id"`
+	UserID    string    `gorm:"type:uuid;primaryKey;index" json:"user_id"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+
+	// Relationships
+	User User `gorm:"foreignKey:UserID"`
+}
@@ -0,0 +1,131 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Events sent to webhooks, "*" subscribes to all of them
+const (
+	EventTaskCreated    = "task.created"
+	EventTaskUpdated    = "task.updated"
+	EventTaskDeleted    = "task.deleted"
+	EventCommentCreated = "comment.created"
+	EventHistoryCreated = "history.created"
+	EventAll            = "*"
+)
+
+var Events = []string{EventTaskCreated, EventTaskUpdated, EventTaskDeleted, EventCommentCreated, EventHistoryCreated}
+
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// Webhook posts events to a URL. Project webhooks get the events of the project's tasks,
+// the others those of tasks without a project and of the projects their owner belongs to.
+type Webhook struct {
+	ID        string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID    string    `gorm:"type:uuid;not null;index" json:"user_id"` // owner
+	ProjectID *string   `gorm:"type:uuid;default:NULL;index" json:"project_id"`
+	URL       string    `gorm:"not null" json:"url"`
+	Secret    string    `gorm:"not null" json:"-"` // signs the payloads
+	Events    []string  `gorm:"type:jsonb;serializer:json;not null" json:"events"`
+	Active    bool      `gorm:"not null;default:true" json:"active"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+
+	// Relationships
+	Project Project `gorm:"foreignKey:ProjectID"`
+}
+
+// WebhookDelivery is one event queued for a webhook, retried with backoff until it succeeds
+// or runs out of attempts
+type WebhookDelivery struct {
+	ID             string     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	WebhookID      string     `gorm:"type:uuid;not null;index" json:"webhook_id"`
+	EventID        string     `gorm:"type:uuid;not null" json:"event_id"` // shared by the deliveries of one event
+	Event          string     `gorm:"type:varchar(50);not null" json:"event"`
+	Payload        string     `gorm:"type:text;not null" json:"payload"` // the exact body that is signed and sent
+	Status         string     `gorm:"type:varchar(20);not null;default:'pending';index:idx_delivery_queue,priority:1" json:"status"`
+	Attempts       int        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt  time.Time  `gorm:"not null;index:idx_delivery_queue,priority:2" json:"next_attempt_at"`
+	ResponseStatus *int       `gorm:"default:NULL" json:"response_status"`
+	Error          string     `json:"error"`
+	DeliveredAt    *time.Time `gorm:"default:NULL" json:"delivered_at"`
+	CreatedAt      time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+type WebhookResponse struct {
+	ID         string    `json:"id"`
+	URL        string    `json:"url"`
+	Events     []string  `json:"events"`
+	Active     bool      `json:"active"`
+	ProjectKey *string   `json:"project_key,omitempty"`
+	Secret     string    `json:"secret,omitempty"` // only when created or rotated
+	CreatedBy  string    `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func FormatWebhookResponse(webhook Webhook) WebhookResponse {
+	var projectKey *string
+	if webhook.ProjectID != nil && webhook.Project.Key != "" {
+		projectKey = &webhook.Project.Key
+	}
+	return WebhookResponse{
+		ID:         webhook.ID,
+		URL:        webhook.URL,
+		Events:     webhook.Events,
+		Active:     webhook.Active,
+		ProjectKey: projectKey,
+		CreatedBy:  webhook.UserID,
+		CreatedAt:  webhook.CreatedAt,
+		UpdatedAt:  webhook.UpdatedAt,
+	}
+}
+
+type WebhookDeliveryResponse struct {
+	ID             string          `json:"id"`
+	WebhookID      string          `json:"webhook_id"`
+	EventID        string          `json:"event_id"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty"` // while pending
+	ResponseStatus *int            `json:"response_status"`
+	Error          string          `json:"error,omitempty"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+func FormatWebhookDeliveryResponse(delivery WebhookDelivery) WebhookDeliveryResponse {
+	var nextAttemptAt *time.Time
+	if delivery.Status == DeliveryPending {
+		nextAttemptAt = &delivery.NextAttemptAt
+	}
+	return WebhookDeliveryResponse{
+		ID:             delivery.ID,
+		WebhookID:      delivery.WebhookID,
+		EventID:        delivery.EventID,
+		Event:          delivery.Event,
+		Payload:        json.RawMessage(delivery.Payload),
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  nextAttemptAt,
+		ResponseStatus: delivery.ResponseStatus,
+		Error:          delivery.Error,
+		DeliveredAt:    delivery.DeliveredAt,
+		CreatedAt:      delivery.CreatedAt,
+	}
+}
+
+// WebhookPayload is the JSON body posted to webhooks
+type WebhookPayload struct {
+	ID        string      `json:"id"` // event ID
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
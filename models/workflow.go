@@ -0,0 +1,118 @@
+package models
+
+import (
+	"task-management-api/utils"
+	"time"
+)
+
+// State categories let features like blockers and overdue tracking work with custom states
+const (
+	CategoryTodo       = "todo"
+	CategoryInProgress = "in_progress"
+	CategoryDone       = "done"
+)
+
+type Workflow struct {
+	ID           string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ProjectID    *string   `gorm:"type:uuid;uniqueIndex;default:NULL" json:"project_id"` // NULL for the default workflow
+	Name         string    `gorm:"not null" json:"name"`
+	InitialState string    `gorm:"type:varchar(20);not null" json:"initial_state"`
+	CreatedAt    time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt    time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+
+	// Relationships
+	States      []WorkflowState      `gorm:"foreignKey:WorkflowID;constraint:OnDelete:CASCADE" json:"states"`
+	Transitions []WorkflowTransition `gorm:"foreignKey:WorkflowID;constraint:OnDelete:CASCADE" json:"transitions"`
+}
+
+type WorkflowState struct {
+	ID         string `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"-"`
+	WorkflowID string `gorm:"type:uuid;not null;uniqueIndex:idx_workflow_state" json:"-"`
+	Name       string `gorm:"type:varchar(20);not null;uniqueIndex:idx_workflow_state" json:"name"`
+	Label      string `json:"label"`
+	Category   string `gorm:"type:varchar(20);not null" json:"category"`
+	Position   int    `gorm:"not null" json:"position"` // column order on the board
+}
+
+type WorkflowTransition struct {
+	ID           string `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"-"`
+	WorkflowID   string `gorm:"type:uuid;not null;index" json:"-"`
+	FromState    string `gorm:"type:varchar(20);not null" json:"from"`
+	ToState      string `gorm:"type:varchar(20);not null" json:"to"`
+	RequiredRole string `json:"required_role,omitempty"` // empty means any role
+}
+
+// State returns the state with the given name
+func (w Workflow) State(name string) (WorkflowState, bool) {
+	for _, state := range w.States {
+		if state.Name == name {
+			return state, true
+		}
+	}
+	return WorkflowState{}, false
+}
+
+// Transition returns the transition between two states if the workflow allows it
+func (w Workflow) Transition(from string, to string) (WorkflowTransition, bool) {
+	for _, transition := range w.Transitions {
+		if transition.FromState == from && transition.ToState == to {
+			return transition, true
+		}
+	}
+	return WorkflowTransition{}, false
+}
+
+// StateNames lists the states in board order
+func (w Workflow) StateNames() []string {
+	names := make([]string, 0, len(w.States))
+	for _, state := range w.States {
+		names = append(names, state.Name)
+	}
+	return names
+}
+
+// DoneStates lists the states in the done category
+func (w Workflow) DoneStates() []string {
+	var names []string
+	for _, state := range w.States {
+		if state.Category == CategoryDone {
+			names = append(names, state.Name)
+		}
+	}
+	return names
+}
+
+// DefaultWorkflow is seeded into the database and used by tasks without a custom workflow
+func DefaultWorkflow() Workflow {
+	states := []WorkflowState{
+		{Name: string(utils.Todo), Label: "To Do", Category: CategoryTodo},
+		{Name: string(utils.InProgress), Label: "In Progress", Category: CategoryInProgress},
+		{Name: string(utils.InReview), Label: "In Review", Category: CategoryInProgress},
+		{Name: string(utils.Done), Label: "Done", Category: CategoryDone},
+		{Name: string(utils.Archive), Label: "Archive", Category: CategoryDone},
+	}
+	for i := range states {
+		states[i].Position = i
+	}
+
+	allowed := map[utils.Status][]utils.Status{
+		utils.Todo:       {utils.InProgress, utils.Done, utils.Archive},
+		utils.InProgress: {utils.Todo, utils.InReview, utils.Done, utils.Archive},
+		utils.InReview:   {utils.InProgress, utils.Done, utils.Archive},
+		utils.Done:       {utils.InProgress, utils.Archive},
+		utils.Archive:    {utils.Todo},
+	}
+	var transitions []WorkflowTransition
+	for _, state := range states {
+		for _, to := range allowed[utils.Status(state.Name)] {
+			transitions = append(transitions, WorkflowTransition{FromState: state.Name, ToState: string(to)})
+		}
+	}
+
+	return Workflow{
+		Name:         "Default",
+		InitialState: string(utils.Todo),
+		States:       states,
+		Transitions:  transitions,
+	}
+}
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"task-management-api/handlers"
+	"task-management-api/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func AttachmentRoutes(route fiber.Router) {
+	withAuthRoute := route.Group("/attachments", middleware.AuthMiddleware)
+
+	withAuthRoute.Get("/:id/download", handlers.DownloadAttachment)
+	withAuthRoute.Delete("/:id", handlers.DeleteAttachment)
+}
@@ -2,6 +2,7 @@ package routes
 
 import (
 	"task-management-api/handlers"
+	"task-management-api/middleware"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -12,4 +13,6 @@ func AuthRoutes(route fiber.Router) {
 
 	auth.Post("/signup", handlers.SignUp)
 	auth.Post("/login", handlers.Login)
+	auth.Post("/refresh", handlers.Refresh)
+	auth.Post("/logout", middleware.AuthMiddleware, handlers.Logout)
 }
@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"task-management-api/handlers"
+	"task-management-api/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func BoardRoutes(route fiber.Router) {
+	// The board of the tasks without a project, authentication is optional so filters can use "me"
+	route.Get("/board", middleware.OptionalAuthMiddleware, handlers.GetBoard)
+}
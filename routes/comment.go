@@ -8,10 +8,17 @@ import (
 )
 
 func CommentRoutes(route fiber.Router) {
+	publicTask := route.Group("/tasks/:id/comments")
 	task := route.Group("/tasks/:id/comments", middleware.AuthMiddleware)
+	publicComment := route.Group("/comments")
 	comment := route.Group("/comments", middleware.AuthMiddleware)
 
+	publicTask.Get("/", middleware.OptionalAuthMiddleware, handlers.GetTaskComments)
+	publicComment.Get("/:id/reactions", middleware.OptionalAuthMiddleware, handlers.GetCommentReactions)
 	task.Post("/", handlers.CreateComment)
 	comment.Put("/:id", handlers.UpdateComment)
 	comment.Delete("/:id", handlers.DeleteComment)
+	comment.Post("/:id/attachments", handlers.UploadCommentAttachment)
+	comment.Post("/:id/reactions", handlers.AddCommentReaction)
+	comment.Delete("/:id/reactions/:emoji", handlers.RemoveCommentReaction)
 }
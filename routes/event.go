@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"task-management-api/handlers"
+	"task-management-api/middleware"
+	"task-management-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func EventRoutes(route fiber.Router) {
+	// Only roles with event:manage can look into and retry failed domain events
+	event := route.Group("/events", middleware.AuthMiddleware, middleware.PermissionMiddleware(utils.EventManage))
+
+	event.Get("/failed", handlers.GetFailedEvents)
+	event.Post("/:id/retry", handlers.RetryEvent)
+}
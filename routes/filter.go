@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"task-management-api/handlers"
+	"task-management-api/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func FilterRoutes(route fiber.Router) {
+	filter := route.Group("/filters", middleware.AuthMiddleware)
+
+	filter.Get("/", handlers.GetAllSavedFilters)
+	filter.Post("/", handlers.CreateSavedFilter)
+	filter.Get("/:id", handlers.GetSavedFilterById)
+	filter.Put("/:id", handlers.UpdateSavedFilter)
+	filter.Delete("/:id", handlers.DeleteSavedFilter)
+}
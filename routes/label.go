@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"task-management-api/handlers"
+	"task-management-api/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func LabelRoutes(route fiber.Router) {
+	label := route.Group("/labels", middleware.AuthMiddleware)
+
+	label.Get("/", handlers.GetAllLabels)
+	label.Post("/", handlers.CreateLabel)
+	label.Put("/:id", handlers.UpdateLabel)
+	label.Post("/:id/merge", handlers.MergeLabel)
+	label.Delete("/:id", handlers.DeleteLabel)
+}
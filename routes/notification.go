@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"task-management-api/handlers"
+	"task-management-api/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func NotificationRoutes(route fiber.Router) {
+	notification := route.Group("/notifications", middleware.AuthMiddleware)
+
+	notification.Get("/", handlers.GetNotifications)
+	notification.Get("/unread-count", handlers.GetUnreadNotificationCount)
+	notification.Post("/read", handlers.MarkAllNotificationsRead)
+	notification.Post("/:id/read", handlers.MarkNotificationRead)
+}
@@ -0,0 +1,41 @@
+package routes
+
+import (
+	"task-management-api/handlers"
+	"task-management-api/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func ProjectRoutes(route fiber.Router) {
+	project := route.Group("/projects", middleware.AuthMiddleware)
+
+	project.Get("/", handlers.GetAllProjects)
+	project.Post("/", handlers.CreateProject)
+	project.Get("/:key", handlers.GetProjectByKey)
+	project.Put("/:key", handlers.UpdateProject)
+	project.Delete("/:key", handlers.DeleteProject)
+
+	// Only the project owner (or an admin) can manage members
+	project.Post("/:key/members", handlers.AddProjectMember)
+	project.Delete("/:key/members/:userId", handlers.RemoveProjectMember)
+
+	// Board columns and allowed status transitions
+	project.Get("/:key/workflow", handlers.GetProjectWorkflow)
+	project.Put("/:key/workflow", handlers.UpdateProjectWorkflow)
+	project.Get("/:key/board", handlers.GetProjectBoard)
+
+	// Custom task fields, defined by the project owner (or an admin)
+	project.Get("/:key/fields", handlers.GetCustomFields)
+	project.Post("/:key/fields", handlers.CreateCustomField)
+	project.Put("/:key/fields/:fieldId", handlers.UpdateCustomField)
+	project.Delete("/:key/fields/:fieldId", handlers.DeleteCustomField)
+
+	// Sprints are planned by the project members
+	project.Get("/:key/sprints", handlers.GetProjectSprints)
+	project.Post("/:key/sprints", handlers.CreateSprint)
+
+	// Project members can list and create tasks in the project
+	project.Get("/:key/tasks", handlers.GetProjectTasks)
+	project.Post("/:key/tasks", handlers.CreateProjectTask)
+}
@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"task-management-api/handlers"
+	"task-management-api/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func SprintRoutes(route fiber.Router) {
+	sprint := route.Group("/sprints", middleware.AuthMiddleware)
+
+	sprint.Get("/:id", handlers.GetSprint)
+	sprint.Put("/:id", handlers.UpdateSprint)
+	sprint.Delete("/:id", handlers.DeleteSprint)
+	sprint.Post("/:id/tasks", handlers.AddSprintTasks)
+	sprint.Delete("/:id/tasks/:taskId", handlers.RemoveSprintTask)
+	sprint.Post("/:id/start", handlers.StartSprint)
+	sprint.Post("/:id/complete", handlers.CompleteSprint)
+	sprint.Get("/:id/report", handlers.GetSprintReport)
+}
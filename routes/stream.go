@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"task-management-api/handlers"
+	"task-management-api/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func StreamRoutes(route fiber.Router) {
+	route.Get("/stream", middleware.QueryTokenAuthMiddleware, handlers.Stream)
+}
@@ -11,11 +11,25 @@ func TaskRoutes(route fiber.Router) {
 	publicRoute := route.Group("/tasks")
 	withAuthRoute := route.Group("/tasks", middleware.AuthMiddleware)
 
-	publicRoute.Get("/", handlers.GetAllTasks)
-	publicRoute.Get("/:id", handlers.GetTaskById)
+	// Authentication is optional so filters can use "me" and project members see their project tasks
+	publicRoute.Get("/", middleware.OptionalAuthMiddleware, handlers.GetAllTasks)
+	publicRoute.Get("/:id", middleware.OptionalAuthMiddleware, handlers.GetTaskById)
+	publicRoute.Get("/:id/subtasks", middleware.OptionalAuthMiddleware, handlers.GetSubtasks)
+	publicRoute.Get("/:id/links", middleware.OptionalAuthMiddleware, handlers.GetTaskLinks)
+	publicRoute.Get("/:id/history", middleware.OptionalAuthMiddleware, handlers.GetTaskHistory)
+	publicRoute.Get("/:id/watchers", middleware.OptionalAuthMiddleware, handlers.GetTaskWatchers)
+	publicRoute.Get("/:id/attachments", middleware.OptionalAuthMiddleware, handlers.GetTaskAttachments)
 
 	// Only authenticated users can create, update, and delete tasks
 	withAuthRoute.Post("/", handlers.CreateTask)
 	withAuthRoute.Put("/:id", handlers.UpdateTask)
+	withAuthRoute.Post("/:id/move", handlers.MoveTask)
 	withAuthRoute.Delete("/:id", handlers.DeleteTask)
+	withAuthRoute.Post("/:id/links", handlers.CreateTaskLink)
+	withAuthRoute.Delete("/:id/links/:linkId", handlers.DeleteTaskLink)
+	withAuthRoute.Post("/:id/labels", handlers.AddTaskLabel)
+	withAuthRoute.Delete("/:id/labels/:labelId", handlers.RemoveTaskLabel)
+	withAuthRoute.Post("/:id/watch", handlers.WatchTask)
+	withAuthRoute.Delete("/:id/watch", handlers.UnwatchTask)
+	withAuthRoute.Post("/:id/attachments", handlers.UploadTaskAttachment)
 }
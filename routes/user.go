@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"task-management-api/handlers"
+	"task-management-api/middleware"
+	"task-management-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func UserRoutes(route fiber.Router) {
+	// Only roles with user:manage can list users and change roles
+	user := route.Group("/users", middleware.AuthMiddleware, middleware.PermissionMiddleware(utils.UserManage))
+
+	user.Get("/", handlers.GetAllUsers)
+	user.Put("/:id/role", handlers.UpdateUserRole)
+}
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"task-management-api/handlers"
+	"task-management-api/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func WebhookRoutes(route fiber.Router) {
+	webhook := route.Group("/webhooks", middleware.AuthMiddleware)
+
+	webhook.Get("/", handlers.GetWebhooks)
+	webhook.Post("/", handlers.CreateWebhook)
+	webhook.Get("/:id", handlers.GetWebhook)
+	webhook.Put("/:id", handlers.UpdateWebhook)
+	webhook.Delete("/:id", handlers.DeleteWebhook)
+	webhook.Get("/:id/deliveries", handlers.GetWebhookDeliveries)
+	webhook.Post("/:id/deliveries/:deliveryId/redeliver", handlers.RedeliverWebhook)
+}
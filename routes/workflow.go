@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"task-management-api/handlers"
+	"task-management-api/middleware"
+	"task-management-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func WorkflowRoutes(route fiber.Router) {
+	workflow := route.Group("/workflows", middleware.AuthMiddleware)
+
+	workflow.Get("/default", handlers.GetDefaultWorkflow)
+	workflow.Put("/default", middleware.PermissionMiddleware(utils.WorkflowManage), handlers.UpdateDefaultWorkflow)
+}
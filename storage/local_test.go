@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalPutGetDelete(t *testing.T) {
+	files := NewLocal(t.TempDir())
+	const key = "tasks/5b0e7c1a-2f4d-4e8a-9c3b-7d6e5f4a3b21/0c9d8e7f-6a5b-4c3d-8e2f-1a0b9c8d7e65"
+
+	if err := files.Put(key, strings.NewReader("hello"), "text/plain"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	file, err := files.Get(key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	data, err := io.ReadAll(file)
+	file.Close()
+	if err != nil || string(data) != "hello" {
+		t.Fatalf("Get read %q, %v, want hello", data, err)
+	}
+
+	// Put replaces the contents
+	if err := files.Put(key, strings.NewReader("bye"), "text/plain"); err != nil {
+		t.Fatalf("Put again: %v", err)
+	}
+	file, _ = files.Get(key)
+	data, _ = io.ReadAll(file)
+	file.Close()
+	if string(data) != "bye" {
+		t.Fatalf("Get after Put again read %q, want bye", data)
+	}
+
+	if err := files.Delete(key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := files.Get(key); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get after Delete = %v, want %v", err, ErrNotFound)
+	}
+	if err := files.Delete(key); err != nil {
+		t.Fatalf("Delete of a missing file = %v, want it ignored", err)
+	}
+}
+
+func TestLocalRejectsKeysOutsideRoot(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "uploads")
+	files := NewLocal(root)
+
+	outside := filepath.Join(dir, "secret")
+	if err := os.WriteFile(outside, []byte("secret"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"../secret", "tasks/../../secret", "/etc/passwd", "", "tasks/../../../secret"} {
+		if err := files.Put(key, strings.NewReader("x"), "text/plain"); err == nil {
+			t.Errorf("Put(%q) succeeded, want it rejected", key)
+		}
+		if file, err := files.Get(key); err == nil {
+			file.Close()
+			t.Errorf("Get(%q) succeeded, want it rejected", key)
+		}
+		if err := files.Delete(key); err == nil {
+			t.Errorf("Delete(%q) succeeded, want it rejected", key)
+		}
+	}
+
+	if data, err := os.ReadFile(outside); err != nil || string(data) != "secret" {
+		t.Fatalf("file outside the root was touched: %q, %v", data, err)
+	}
+	if _, err := os.Stat(root); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("rejected keys created %s", root)
+	}
+}
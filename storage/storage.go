@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Storage keeps the contents of attachments under a key like tasks/<task id>/<attachment id>
+type Storage interface {
+	Put(key string, data io.Reader, contentType string) error
+	Get(key string) (io.ReadCloser, error)
+	Delete(keys ...string) error
+}
+
+// URLSigner is implemented by backends that can hand out short-lived download URLs,
+// downloads from other backends are streamed through the API
+type URLSigner interface {
+	SignedURL(key string, expiresIn time.Duration) (string, error)
+}
+
+var ErrNotFound = errors.New("file not found")
+
+// Files is the backend selected by Init
+var Files Storage
+
+// Upload limits, see Init
+var (
+	MaxSize      int64 = 10 << 20
+	AllowedTypes       = []string{
+		"image/png", "image/jpeg", "image/gif", "image/webp",
+		"text/plain", "text/csv", "application/pdf", "application/zip", "application/json",
+	}
+)
+
+// Init selects the backend from STORAGE_BACKEND (local, the default, or supabase) and reads
+// ATTACHMENT_MAX_SIZE (bytes) and ATTACHMENT_TYPES (comma separated MIME types)
+func Init() {
+	if size, err := strconv.ParseInt(os.Getenv("ATTACHMENT_MAX_SIZE"), 10, 64); err == nil && size > 0 {
+		MaxSize = size
+	}
+	if types := os.Getenv("ATTACHMENT_TYPES"); types != "" {
+		AllowedTypes = nil
+		for _, t := range strings.Split(types, ",") {
+			AllowedTypes = append(AllowedTypes, strings.ToLower(strings.TrimSpace(t)))
+		}
+	}
+
+	switch backend := os.Getenv("STORAGE_BACKEND"); backend {
+	case "", "local":
+		dir := os.Getenv("STORAGE_LOCAL_DIR")
+		if dir == "" {
+			dir = "uploads"
+		}
+		Files = NewLocal(dir)
+	case "supabase":
+		bucket := os.Getenv("STORAGE_BUCKET")
+		if bucket == "" {
+			bucket = "attachments"
+		}
+		Files = NewSupabase(bucket)
+	default:
+		log.Fatal("Unknown STORAGE_BACKEND: ", backend)
+	}
+}
@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"task-management-api/config"
+	"time"
+
+	storage_go "github.com/supabase-community/storage-go"
+)
+
+// Supabase keeps files in a Supabase Storage bucket, using the client from config.InitSupabase
+type Supabase struct {
+	Bucket string
+}
+
+func NewSupabase(bucket string) *Supabase {
+	if config.SupabaseClient == nil {
+		config.InitSupabase()
+	}
+	return &Supabase{Bucket: bucket}
+}
+
+// Put uploads through a client of its own, since storage-go keeps the content type of an
+// upload on the client and would send it with every later request
+func (s *Supabase) Put(key string, data io.Reader, contentType string) error {
+	client := storage_go.NewClient(os.Getenv("SUPABASE_URL")+"/storage/v1", os.Getenv("SUPABASE_KEY"), nil)
+	_, err := client.UploadFile(s.Bucket, key, data, storage_go.FileOptions{ContentType: &contentType})
+	return err
+}
+
+func (s *Supabase) Get(key string) (io.ReadCloser, error) {
+	data, err := config.SupabaseClient.Storage.DownloadFile(s.Bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *Supabase) Delete(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	_, err := config.SupabaseClient.Storage.RemoveFile(s.Bucket, keys)
+	return err
+}
+
+func (s *Supabase) SignedURL(key string, expiresIn time.Duration) (string, error) {
+	response, err := config.SupabaseClient.Storage.CreateSignedUrl(s.Bucket, key, int(expiresIn.Seconds()))
+	if err != nil {
+		return "", err
+	}
+	return response.SignedURL, nil
+}
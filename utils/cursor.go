@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor marks a position in a list ordered by (time, id).
+// Before means the page ends just before this position instead of starting after it.
+type Cursor struct {
+	Time   time.Time `json:"t"`
+	ID     string    `json:"id"`
+	Before bool      `json:"b,omitempty"`
+}
+
+// EncodeCursor returns an opaque token for the cursor
+func EncodeCursor(cursor Cursor) string {
+	b, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func DecodeCursor(token string) (Cursor, error) {
+	var cursor Cursor
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return cursor, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(b, &cursor); err != nil || cursor.ID == "" {
+		return cursor, ErrInvalidCursor
+	}
+	return cursor, nil
+}
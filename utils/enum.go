@@ -1,10 +1,51 @@
 package utils
 
+// Status names of the default workflow, projects can define their own
 type Status string
 
 const (
 	Todo       Status = "TODO"
 	InProgress Status = "IN_PROGRESS"
+	InReview   Status = "IN_REVIEW"
 	Done       Status = "DONE"
 	Archive    Status = "ARCHIVE"
 )
+
+// Priority of a task, from lowest to highest
+type Priority string
+
+const (
+	Lowest  Priority = "LOWEST"
+	Low     Priority = "LOW"
+	Medium  Priority = "MEDIUM"
+	High    Priority = "HIGH"
+	Highest Priority = "HIGHEST"
+)
+
+var Priorities = []Priority{Lowest, Low, Medium, High, Highest}
+
+// Severity of a bug, from least to most severe
+type Severity string
+
+const (
+	Trivial  Severity = "TRIVIAL"
+	Minor    Severity = "MINOR"
+	Major    Severity = "MAJOR"
+	Critical Severity = "CRITICAL"
+	Blocker  Severity = "BLOCKER"
+)
+
+var Severities = []Severity{Trivial, Minor, Major, Critical, Blocker}
+
+// Type of a task, deciding which fields it requires
+type TaskType string
+
+const (
+	PlainTask TaskType = "TASK"
+	Bug       TaskType = "BUG"
+	Story     TaskType = "STORY"
+	Epic      TaskType = "EPIC"
+	Chore     TaskType = "CHORE"
+)
+
+var TaskTypes = []TaskType{PlainTask, Bug, Story, Epic, Chore}
@@ -0,0 +1,397 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+type FieldType int
+
+const (
+	TextField FieldType = iota
+	EnumField
+	UserField
+	TimeField
+	NumberField
+	IDField // a uuid column, compared with =, != and in only
+)
+
+// FilterField maps a field name usable in filter and sort expressions to its column.
+// Order, when set, is the expression to sort by instead of the column.
+type FilterField struct {
+	Column string
+	Type   FieldType
+	Order  string
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// ParseFilter turns an expression like
+//
+//	status in (TODO,IN_PROGRESS) and updated_at > 2026-01-01 and assignee = me
+//
+// into a SQL condition with placeholders. Only whitelisted fields are accepted,
+// so column names never come from user input. "me" resolves to currentUserID.
+func ParseFilter(expr string, fields map[string]FilterField, currentUserID string) (string, []interface{}, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return "", nil, err
+	}
+
+	p := &filterParser{tokens: tokens, fields: fields, me: currentUserID}
+	sql, err := p.parseOr()
+	if err != nil {
+		return "", nil, err
+	}
+	if !p.done() {
+		return "", nil, fmt.Errorf("unexpected %q", p.peek().text)
+	}
+
+	return sql, p.args, nil
+}
+
+// ParseSort turns "-priority,updated_at" into an ORDER BY clause, "-" meaning descending
+func ParseSort(sort string, fields map[string]FilterField) (string, error) {
+	var orders []string
+	for _, part := range strings.Split(sort, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		direction := "ASC"
+		if strings.HasPrefix(part, "-") {
+			direction = "DESC"
+			part = part[1:]
+		} else if strings.HasPrefix(part, "+") {
+			part = part[1:]
+		}
+
+		field, ok := fields[part]
+		if !ok {
+			return "", fmt.Errorf("cannot sort by unknown field %q", part)
+		}
+		column := field.Column
+		if field.Order != "" {
+			column = field.Order
+		}
+		orders = append(orders, column+" "+direction)
+	}
+
+	return strings.Join(orders, ", "), nil
+}
+
+type tokenKind int
+
+const (
+	wordToken tokenKind = iota
+	stringToken
+	symbolToken
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+func tokenize(expr string) ([]token, error) {
+	var tokens []token
+	runes := []rune(expr)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')' || r == ',':
+			tokens = append(tokens, token{symbolToken, string(r)})
+			i++
+		case r == '=' || r == '~':
+			tokens = append(tokens, token{symbolToken, string(r)})
+			i++
+		case r == '!' || r == '<' || r == '>':
+			if i+1 < len(runes) && runes[i+1] == '=' {
+				tokens = append(tokens, token{symbolToken, string(runes[i : i+2])})
+				i += 2
+			} else if r == '!' {
+				return nil, fmt.Errorf("unexpected %q", string(r))
+			} else {
+				tokens = append(tokens, token{symbolToken, string(r)})
+				i++
+			}
+		case r == '"' || r == '\'':
+			end := i + 1
+			for end < len(runes) && runes[end] != r {
+				end++
+			}
+			if end == len(runes) {
+				return nil, fmt.Errorf("unterminated string starting at %d", i)
+			}
+			tokens = append(tokens, token{stringToken, string(runes[i+1 : end])})
+			i = end + 1
+		default:
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && !strings.ContainsRune("()=,!<>~\"'", runes[end]) {
+				end++
+			}
+			tokens = append(tokens, token{wordToken, string(runes[i:end])})
+			i = end
+		}
+	}
+
+	return tokens, nil
+}
+
+type filterParser struct {
+	tokens []token
+	pos    int
+	fields map[string]FilterField
+	me     string
+	args   []interface{}
+}
+
+func (p *filterParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *filterParser) peek() token {
+	if p.done() {
+		return token{}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) next() (token, error) {
+	if p.done() {
+		return token{}, fmt.Errorf("unexpected end of filter")
+	}
+	t := p.tokens[p.pos]
+	p.pos++
+	return t, nil
+}
+
+// keyword consumes the next token if it is the given case-insensitive keyword
+func (p *filterParser) keyword(word string) bool {
+	t := p.peek()
+	if !p.done() && t.kind == wordToken && strings.EqualFold(t.text, word) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *filterParser) symbol(s string) bool {
+	t := p.peek()
+	if !p.done() && t.kind == symbolToken && t.text == s {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *filterParser) parseOr() (string, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return "", err
+	}
+	for p.keyword("or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return "", err
+		}
+		left = "(" + left + " OR " + right + ")"
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (string, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return "", err
+	}
+	for p.keyword("and") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return "", err
+		}
+		left = left + " AND " + right
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (string, error) {
+	if p.keyword("not") {
+		inner, err := p.parseUnary()
+		if err != nil {
+			return "", err
+		}
+		return "NOT (" + inner + ")", nil
+	}
+	if p.symbol("(") {
+		inner, err := p.parseOr()
+		if err != nil {
+			return "", err
+		}
+		if !p.symbol(")") {
+			return "", fmt.Errorf("missing closing parenthesis")
+		}
+		return "(" + inner + ")", nil
+	}
+	return p.parseComparison()
+}
+
+func (p *filterParser) parseComparison() (string, error) {
+	t, err := p.next()
+	if err != nil {
+		return "", err
+	}
+	if t.kind != wordToken {
+		return "", fmt.Errorf("expected a field name, got %q", t.text)
+	}
+	name := strings.ToLower(t.text)
+	field, ok := p.fields[name]
+	if !ok {
+		return "", fmt.Errorf("unknown field %q", t.text)
+	}
+
+	// field is [not] null
+	if p.keyword("is") {
+		if p.keyword("not") {
+			if !p.keyword("null") {
+				return "", fmt.Errorf("expected null after %s is not", name)
+			}
+			return field.Column + " IS NOT NULL", nil
+		}
+		if !p.keyword("null") {
+			return "", fmt.Errorf("expected null after %s is", name)
+		}
+		return field.Column + " IS NULL", nil
+	}
+
+	// field [not] in (a, b)
+	negate := p.keyword("not")
+	if p.keyword("in") {
+		if !p.symbol("(") {
+			return "", fmt.Errorf("expected ( after in")
+		}
+		var values []interface{}
+		for {
+			value, err := p.parseValue(name, field)
+			if err != nil {
+				return "", err
+			}
+			values = append(values, value)
+			if p.symbol(")") {
+				break
+			}
+			if !p.symbol(",") {
+				return "", fmt.Errorf("expected , or ) in list for %s", name)
+			}
+		}
+		p.args = append(p.args, values)
+		if negate {
+			return field.Column + " NOT IN ?", nil
+		}
+		return field.Column + " IN ?", nil
+	}
+	if negate {
+		return "", fmt.Errorf("expected in after %s not", name)
+	}
+
+	op, err := p.next()
+	if err != nil {
+		return "", err
+	}
+	if op.kind != symbolToken {
+		return "", fmt.Errorf("expected an operator after %s, got %q", name, op.text)
+	}
+
+	switch op.text {
+	case "=", "!=":
+	case "<", "<=", ">", ">=":
+		if field.Type != TimeField && field.Type != NumberField {
+			return "", fmt.Errorf("operator %s is not supported for %s", op.text, name)
+		}
+	case "~":
+		if field.Type != TextField {
+			return "", fmt.Errorf("operator ~ is only supported for text fields")
+		}
+		value, err := p.parseValue(name, field)
+		if err != nil {
+			return "", err
+		}
+		p.args = append(p.args, "%"+escapeLike(value.(string))+"%")
+		return field.Column + " ILIKE ?", nil
+	default:
+		return "", fmt.Errorf("unknown operator %q", op.text)
+	}
+
+	value, err := p.parseValue(name, field)
+	if err != nil {
+		return "", err
+	}
+	p.args = append(p.args, value)
+	if op.text == "!=" {
+		return field.Column + " <> ?", nil
+	}
+	return field.Column + " " + op.text + " ?", nil
+}
+
+func (p *filterParser) parseValue(name string, field FilterField) (interface{}, error) {
+	t, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if t.kind == symbolToken {
+		return nil, fmt.Errorf("expected a value for %s, got %q", name, t.text)
+	}
+
+	switch field.Type {
+	case UserField:
+		if t.kind == wordToken && strings.EqualFold(t.text, "me") {
+			if p.me == "" {
+				return nil, fmt.Errorf("%s = me requires authentication", name)
+			}
+			return p.me, nil
+		}
+		if !uuidPattern.MatchString(t.text) {
+			return nil, fmt.Errorf("%s must be a user ID or me", name)
+		}
+	case IDField:
+		if !uuidPattern.MatchString(t.text) {
+			return nil, fmt.Errorf("%s must be an ID", name)
+		}
+	case TimeField:
+		value, err := ParseTime(t.text)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a date (2006-01-02) or RFC 3339 time", name)
+		}
+		return value, nil
+	case NumberField:
+		value, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a number", name)
+		}
+		return value, nil
+	case EnumField:
+		return strings.ToUpper(t.text), nil
+	}
+
+	return t.text, nil
+}
+
+// ParseTime accepts a date (2006-01-02) or an RFC 3339 timestamp
+func ParseTime(s string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
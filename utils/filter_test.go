@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+var testFields = map[string]FilterField{
+	"id":         {Column: "tasks.id", Type: IDField},
+	"title":      {Column: "tasks.title", Type: TextField},
+	"status":     {Column: "tasks.status", Type: EnumField},
+	"assignee":   {Column: "tasks.assignee", Type: UserField},
+	"updated_at": {Column: "tasks.updated_at", Type: TimeField},
+	"estimate":   {Column: "tasks.estimate", Type: NumberField},
+	"priority":   {Column: "tasks.priority", Type: EnumField, Order: "CASE tasks.priority WHEN 'HIGH' THEN 3 WHEN 'MEDIUM' THEN 2 ELSE 1 END"},
+}
+
+const testUserID = "2f1e0d9c-8b7a-4c6d-9e5f-4a3b2c1d0e9f"
+
+func TestParseFilter(t *testing.T) {
+	for _, tt := range []struct {
+		expr string
+		sql  string
+		args []interface{}
+	}{
+		{"status = todo", "tasks.status = ?", []interface{}{"TODO"}},
+		{"status != DONE", "tasks.status <> ?", []interface{}{"DONE"}},
+		{"status in (TODO, in_progress)", "tasks.status IN ?", []interface{}{[]interface{}{"TODO", "IN_PROGRESS"}}},
+		{"status not in (DONE)", "tasks.status NOT IN ?", []interface{}{[]interface{}{"DONE"}}},
+		{"assignee = me", "tasks.assignee = ?", []interface{}{testUserID}},
+		{"assignee = ME", "tasks.assignee = ?", []interface{}{testUserID}},
+		{"assignee is null", "tasks.assignee IS NULL", nil},
+		{"id = " + testUserID, "tasks.id = ?", []interface{}{testUserID}},
+		{"id in (" + testUserID + ")", "tasks.id IN ?", []interface{}{[]interface{}{testUserID}}},
+		{"assignee IS NOT NULL", "tasks.assignee IS NOT NULL", nil},
+		{"estimate >= 30", "tasks.estimate >= ?", []interface{}{30.0}},
+		{"updated_at > 2026-01-01", "tasks.updated_at > ?", []interface{}{time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}},
+		{`title ~ "50%_off"`, "tasks.title ILIKE ?", []interface{}{`%50\%\_off%`}},
+		{"title = 'two words'", "tasks.title = ?", []interface{}{"two words"}},
+		{
+			"status = TODO and assignee = me or estimate < 10",
+			"(tasks.status = ? AND tasks.assignee = ? OR tasks.estimate < ?)",
+			[]interface{}{"TODO", testUserID, 10.0},
+		},
+		{
+			"status = TODO and (assignee = me or not estimate > 5)",
+			"tasks.status = ? AND ((tasks.assignee = ? OR NOT (tasks.estimate > ?)))",
+			[]interface{}{"TODO", testUserID, 5.0},
+		},
+		{"STATUS = todo AND Title ~ x", "tasks.status = ? AND tasks.title ILIKE ?", []interface{}{"TODO", "%x%"}},
+	} {
+		sql, args, err := ParseFilter(tt.expr, testFields, testUserID)
+		if err != nil {
+			t.Errorf("ParseFilter(%q): %v", tt.expr, err)
+			continue
+		}
+		if sql != tt.sql {
+			t.Errorf("ParseFilter(%q) sql = %q, want %q", tt.expr, sql, tt.sql)
+		}
+		if !reflect.DeepEqual(args, tt.args) {
+			t.Errorf("ParseFilter(%q) args = %#v, want %#v", tt.expr, args, tt.args)
+		}
+	}
+}
+
+func TestParseFilterErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"secret = 1",
+		"tasks.title = x",
+		"title = x; DROP TABLE tasks",
+		"status",
+		"status =",
+		"status = TODO and",
+		"status = TODO extra",
+		"(status = TODO",
+		"status in TODO",
+		"status in (TODO",
+		"status in (TODO DONE)",
+		"status not = TODO",
+		"status is TODO",
+		"status is not TODO",
+		"status ! TODO",
+		"status > TODO",
+		"title < x",
+		"status ~ TODO",
+		"title = 'unterminated",
+		"assignee = someone",
+		"id = abc",
+		"id = me",
+		"id ~ 2f1e",
+		"id > " + testUserID,
+		"estimate = many",
+		"updated_at > yesterday",
+		"= TODO",
+		"title = (",
+	} {
+		if sql, args, err := ParseFilter(expr, testFields, testUserID); err == nil {
+			t.Errorf("ParseFilter(%q) = %q, %v, want an error", expr, sql, args)
+		}
+	}
+
+	// me needs a signed in user
+	if _, _, err := ParseFilter("assignee = me", testFields, ""); err == nil {
+		t.Error("ParseFilter(assignee = me) without a user should fail")
+	}
+}
+
+func TestParseSort(t *testing.T) {
+	for _, tt := range []struct {
+		sort string
+		want string
+	}{
+		{"", ""},
+		{"title", "tasks.title ASC"},
+		{"+title", "tasks.title ASC"},
+		{"-updated_at", "tasks.updated_at DESC"},
+		{"-priority, title", testFields["priority"].Order + " DESC, tasks.title ASC"},
+		{"status,,estimate,", "tasks.status ASC, tasks.estimate ASC"},
+	} {
+		got, err := ParseSort(tt.sort, testFields)
+		if err != nil {
+			t.Errorf("ParseSort(%q): %v", tt.sort, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseSort(%q) = %q, want %q", tt.sort, got, tt.want)
+		}
+	}
+
+	for _, sort := range []string{"secret", "-tasks.title", "title desc", "title;DROP TABLE tasks"} {
+		if got, err := ParseSort(sort, testFields); err == nil {
+			t.Errorf("ParseSort(%q) = %q, want an error", sort, got)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"bytes"
+	"os"
+	"regexp"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// taskKeyInText finds task keys like WEB-12 in running text
+var taskKeyInText = regexp.MustCompile(`\b[A-Z][A-Z0-9]{1,9}-[0-9]+\b`)
+
+var markdown = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+	goldmark.WithParserOptions(parser.WithASTTransformers(util.Prioritized(taskKeyLinker{}, 100))),
+)
+
+// Raw HTML is already left out by goldmark, the policy is what keeps the output safe
+var sanitizer = bluemonday.UGCPolicy()
+
+// RenderMarkdown turns Markdown into sanitized HTML, linking task keys to their task.
+// Links point to TASK_LINK_BASE_URL followed by the key (default /api/v1/tasks/).
+func RenderMarkdown(source string) string {
+	var buf bytes.Buffer
+	if err := markdown.Convert([]byte(source), &buf); err != nil {
+		return sanitizer.Sanitize(source)
+	}
+	return sanitizer.Sanitize(buf.String())
+}
+
+func taskLinkBaseURL() string {
+	if base := os.Getenv("TASK_LINK_BASE_URL"); base != "" {
+		return base
+	}
+	return "/api/v1/tasks/"
+}
+
+// taskKeyLinker turns task keys in text into links, leaving code and existing links alone
+type taskKeyLinker struct{}
+
+func (taskKeyLinker) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	source := reader.Source()
+
+	var texts []*ast.Text
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n.Kind() {
+		case ast.KindLink, ast.KindAutoLink, ast.KindImage, ast.KindCodeSpan, ast.KindCodeBlock, ast.KindFencedCodeBlock, ast.KindHTMLBlock, ast.KindRawHTML:
+			return ast.WalkSkipChildren, nil
+		}
+		if t, ok := n.(*ast.Text); ok {
+			texts = append(texts, t)
+		}
+		return ast.WalkContinue, nil
+	})
+
+	base := taskLinkBaseURL()
+	for _, t := range texts {
+		start := t.Segment.Start
+		matches := taskKeyInText.FindAllIndex(t.Segment.Value(source), -1)
+		if len(matches) == 0 {
+			continue
+		}
+
+		// Split the text around each key, the original node keeps the tail and its line break
+		parent := t.Parent()
+		cursor := 0
+		for _, match := range matches {
+			if match[0] > cursor {
+				parent.InsertBefore(parent, t, ast.NewTextSegment(text.NewSegment(start+cursor, start+match[0])))
+			}
+			key := text.NewSegment(start+match[0], start+match[1])
+			link := ast.NewLink()
+			link.Destination = []byte(base + string(key.Value(source)))
+			link.AppendChild(link, ast.NewTextSegment(key))
+			parent.InsertBefore(parent, t, link)
+			cursor = match[1]
+		}
+		t.Segment = t.Segment.WithStart(start + cursor)
+	}
+}
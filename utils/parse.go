@@ -4,10 +4,11 @@ import (
 	"strconv"
 )
 
-func StrToInt(s string) int {
-	i, err := strconv.Atoi(s)
-	if err != nil {
-		panic(err)
-	}
-	return i
+func StrToInt(s string) (int, error) {
+	return strconv.Atoi(s)
+}
+
+// return true if s is a UUID such as a user or task ID
+func IsUUID(s string) bool {
+	return uuidPattern.MatchString(s)
 }
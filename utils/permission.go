@@ -1,5 +1,53 @@
 package utils
 
-func HasPermission(recordUserID string, userID string) bool {
-	return recordUserID == userID
+type Role string
+
+const (
+	AdminRole Role = "admin"
+	UserRole  Role = "user"
+)
+
+type Permission string
+
+const (
+	TaskUpdateAny         Permission = "task:update:any"
+	TaskDeleteAny         Permission = "task:delete:any"
+	CommentModerate       Permission = "comment:moderate"
+	UserManage            Permission = "user:manage"
+	ProjectManageAny      Permission = "project:manage:any"
+	WorkflowManage        Permission = "workflow:manage"
+	WorkflowTransitionAny Permission = "workflow:transition:any"
+	LabelManage           Permission = "label:manage"
+	AttachmentDeleteAny   Permission = "attachment:delete:any"
+	WebhookManageAny      Permission = "webhook:manage:any"
+	EventManage           Permission = "event:manage"
+	SavedFilterManageAny  Permission = "filter:manage:any"
+)
+
+// permissions maps each role to the permissions it is granted on top of
+// the owner rights every user has on their own records
+var permissions = map[Role][]Permission{
+	AdminRole: {TaskUpdateAny, TaskDeleteAny, CommentModerate, UserManage, ProjectManageAny, WorkflowManage, WorkflowTransitionAny, LabelManage, AttachmentDeleteAny, WebhookManageAny, EventManage, SavedFilterManageAny},
+	UserRole:  {},
+}
+
+// return true if role is one of the known roles
+func ValidateRole(role string) bool {
+	_, ok := permissions[Role(role)]
+	return ok
+}
+
+// RoleHasPermission checks the permission matrix for the given role
+func RoleHasPermission(role string, permission Permission) bool {
+	for _, p := range permissions[Role(role)] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPermission allows the owner of a record, or any role granted the permission
+func HasPermission(role string, permission Permission, recordUserID string, userID string) bool {
+	return recordUserID == userID || RoleHasPermission(role, permission)
 }
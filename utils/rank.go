@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+)
+
+// Ranks order tasks within a board column. They are base-36 fractions compared byte by byte,
+// so a task can always be placed between two others by changing its rank alone.
+const rankDigits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+// MaxRankLength is the length past which a column should be given fresh ranks with SpreadRanks
+const MaxRankLength = 48
+
+var ErrRankOrder = errors.New("rank bounds are out of order")
+
+// RankBetween returns a rank after a and before b. An empty a is the start of the column,
+// an empty b its end. Ranks never end with 0, so there is always room between two of them.
+func RankBetween(a string, b string) (string, error) {
+	if b != "" && a >= b {
+		return "", ErrRankOrder
+	}
+
+	var rank []byte
+	for i := 0; ; i++ {
+		lo := 0
+		if i < len(a) {
+			lo = strings.IndexByte(rankDigits, a[i])
+		}
+		hi := len(rankDigits)
+		if b != "" && i < len(b) {
+			hi = strings.IndexByte(rankDigits, b[i])
+		}
+		if lo < 0 || hi < 0 {
+			return "", errors.New("invalid rank")
+		}
+
+		if hi-lo > 1 {
+			return string(append(rank, rankDigits[(lo+hi)/2])), nil
+		}
+		rank = append(rank, rankDigits[lo])
+		if hi > lo {
+			// the rank is now below b whatever follows, only a bounds the next digits
+			b = ""
+		}
+	}
+}
+
+// SpreadRanks returns n ascending ranks evenly spaced over the whole range
+func SpreadRanks(n int) []string {
+	width := 1
+	for space := len(rankDigits); space <= n*len(rankDigits); space *= len(rankDigits) {
+		width++
+	}
+	space := 1
+	for range width {
+		space *= len(rankDigits)
+	}
+	step := space / (n + 1)
+
+	ranks := make([]string, n)
+	for i := range ranks {
+		value := (i + 1) * step
+		digits := make([]byte, width)
+		for j := width - 1; j >= 0; j-- {
+			digits[j] = rankDigits[value%len(rankDigits)]
+			value /= len(rankDigits)
+		}
+		ranks[i] = strings.TrimRight(string(digits), "0")
+	}
+	return ranks
+}
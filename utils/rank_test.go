@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRankBetween(t *testing.T) {
+	for _, tt := range []struct {
+		a, b string
+	}{
+		{"", ""},
+		{"", "i"},
+		{"i", ""},
+		{"a", "b"},
+		{"a", "a1"},
+		{"a", "a01"},
+		{"a1", "a2"},
+		{"az", "b"},
+		{"zz", ""},
+		{"", "01"},
+		{"", "001"},
+		{"0z", "1"},
+		{"i", "izzzz1"},
+		{"1", "z"},
+	} {
+		rank, err := RankBetween(tt.a, tt.b)
+		if err != nil {
+			t.Errorf("RankBetween(%q, %q): %v", tt.a, tt.b, err)
+			continue
+		}
+		if rank <= tt.a || (tt.b != "" && rank >= tt.b) {
+			t.Errorf("RankBetween(%q, %q) = %q, want it strictly between", tt.a, tt.b, rank)
+		}
+		if strings.HasSuffix(rank, "0") {
+			t.Errorf("RankBetween(%q, %q) = %q ends with 0", tt.a, tt.b, rank)
+		}
+	}
+}
+
+func TestRankBetweenRepeatedly(t *testing.T) {
+	// inserting at the same place over and over keeps the ranks ordered
+	lo, hi := "a", "b"
+	for i := 0; i < 200; i++ {
+		rank, err := RankBetween(lo, hi)
+		if err != nil {
+			t.Fatalf("RankBetween(%q, %q): %v", lo, hi, err)
+		}
+		if rank <= lo || rank >= hi {
+			t.Fatalf("RankBetween(%q, %q) = %q, want it strictly between", lo, hi, rank)
+		}
+		if i%2 == 0 {
+			hi = rank
+		} else {
+			lo = rank
+		}
+	}
+
+	// and appending at the end of a column too
+	last := ""
+	for i := 0; i < 200; i++ {
+		rank, err := RankBetween(last, "")
+		if err != nil || rank <= last {
+			t.Fatalf("RankBetween(%q, \"\") = %q, %v", last, rank, err)
+		}
+		last = rank
+	}
+}
+
+func TestRankBetweenErrors(t *testing.T) {
+	for _, tt := range []struct {
+		a, b string
+	}{
+		{"b", "a"},
+		{"a", "a"},
+		{"a1", "a"},
+	} {
+		if _, err := RankBetween(tt.a, tt.b); !errors.Is(err, ErrRankOrder) {
+			t.Errorf("RankBetween(%q, %q) = %v, want %v", tt.a, tt.b, err, ErrRankOrder)
+		}
+	}
+
+	if _, err := RankBetween("A", ""); err == nil {
+		t.Error("RankBetween(\"A\", \"\") should reject a rank with an invalid digit")
+	}
+}
+
+func TestSpreadRanks(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 35, 36, 37, 100, 1295, 1296, 5000} {
+		ranks := SpreadRanks(n)
+		if len(ranks) != n {
+			t.Fatalf("SpreadRanks(%d) returned %d ranks", n, len(ranks))
+		}
+		for i, rank := range ranks {
+			if rank == "" || strings.HasSuffix(rank, "0") {
+				t.Fatalf("SpreadRanks(%d)[%d] = %q, want a rank not ending with 0", n, i, rank)
+			}
+			if i > 0 && ranks[i-1] >= rank {
+				t.Fatalf("SpreadRanks(%d) is not strictly ascending at %d: %q >= %q", n, i, ranks[i-1], rank)
+			}
+			if len(rank) > MaxRankLength {
+				t.Fatalf("SpreadRanks(%d)[%d] = %q is longer than %d", n, i, rank, MaxRankLength)
+			}
+		}
+
+		// there is room around and between the spread ranks
+		if n > 0 {
+			if _, err := RankBetween("", ranks[0]); err != nil {
+				t.Errorf("no room before SpreadRanks(%d)[0]: %v", n, err)
+			}
+			if _, err := RankBetween(ranks[n-1], ""); err != nil {
+				t.Errorf("no room after SpreadRanks(%d)[%d]: %v", n, n-1, err)
+			}
+		}
+	}
+}
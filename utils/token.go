@@ -1,14 +1,32 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"os"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+const (
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 7 * 24 * time.Hour
+)
+
 func GenerateToken(id string) (string, error) {
+	jti, err := RandomToken(16)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"user_id": id,
+		"jti":     jti,
+		"iat":     now.Unix(),
+		"exp":     now.Add(AccessTokenTTL).Unix(),
 	})
 
 	t, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
@@ -22,10 +40,25 @@ func GenerateToken(id string) (string, error) {
 func VerifyToken(tokenString string) (*jwt.Token, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		return []byte(os.Getenv("JWT_SECRET")), nil
-	})
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
 	if err != nil {
 		return nil, err
 	}
 
 	return token, nil
 }
+
+// RandomToken returns n random bytes encoded as hex
+func RandomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// HashToken hashes opaque tokens before they are stored
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}